@@ -3,10 +3,16 @@ package main
 import (
 	"context"
 	"log"
+	"my_web/backend/internal/analytics"
 	"my_web/backend/internal/article"
+	"my_web/backend/internal/cache"
+	"my_web/backend/internal/comment"
 	"my_web/backend/internal/config"
+	"my_web/backend/internal/event"
 	"my_web/backend/internal/httpserver"
 	"my_web/backend/internal/infra"
+	"my_web/backend/internal/middleware"
+	"my_web/backend/internal/user"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,6 +27,18 @@ func main() {
 		log.Fatalf("读取配置失败: %v", err)
 	}
 
+	if err := config.Article.Validate(); err != nil {
+		log.Fatalf("预览链接密钥无效: %v", err)
+	}
+
+	if err := config.Comment.Validate(); err != nil {
+		log.Fatalf("评论表单密钥无效: %v", err)
+	}
+
+	if err := middleware.InitJWT(&config.Auth); err != nil {
+		log.Fatalf("初始化JWT失败: %v", err)
+	}
+
 	// 初始化应用依赖
 	db, err := infra.InitDatabase(&config.Database)
 
@@ -34,13 +52,36 @@ func main() {
 	}
 
 	ctx := context.Background()
-	articleServ := article.NewArticleService(ctx, db, rdb)
+	cacheStats := cache.NewStats()
+	cacheHandler := cache.NewHandler(cacheStats)
+
+	// 文章变更事件通过 Redis 广播给其他实例
+	bus := event.NewBus(rdb)
+	bus.Start(ctx)
+
+	analyticsServ := analytics.NewAnalyticsService(ctx, db, rdb, &config.Analytics, &config.Site)
+	analyticsHandler := analytics.NewHandler(analyticsServ)
+
+	articleServ := article.NewArticleService(ctx, db, rdb, &config.Article, &config.Site, &config.Cache, analyticsServ, bus, cacheStats)
 	articleHandler := article.NewHandler(articleServ)
 
+	userServ := user.NewUserService(db, rdb, &config.Auth)
+	if err := userServ.EnsureAdmin(ctx); err != nil {
+		log.Fatalf("初始化管理员失败: %v", err)
+	}
+	userHandler := user.NewHandler(userServ)
+
+	commentServ := comment.NewCommentService(db, rdb, articleServ, bus, &config.Comment)
+	commentHandler := comment.NewHandler(commentServ)
+
 	// 在 goroutine 中启动服务
 	srv := httpserver.NewHttpserver(
 		&config.Httpserver,
 		articleHandler,
+		userHandler,
+		commentHandler,
+		analyticsHandler,
+		cacheHandler,
 	)
 
 	go func() {
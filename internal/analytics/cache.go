@@ -0,0 +1,199 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// 分桶数据汇总到数据库后仍保留一段时间，保证跨天的汇总任务能读到前一天的完整数据
+	bucketExpiration = 72 * time.Hour
+)
+
+// cappedIncrScript 给有序集合的成员加 1，集合已有 ARGV[2] 个成员时新成员计入 ARGV[3]
+// KEYS[1] 有序集合；ARGV[1] 成员，ARGV[2] 成员数上限，ARGV[3] 超出上限时使用的成员，ARGV[4] 过期秒数
+var cappedIncrScript = redis.NewScript(`
+local member = ARGV[1]
+if not redis.call('ZSCORE', KEYS[1], member) and redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[2]) then
+	member = ARGV[3]
+end
+redis.call('ZINCRBY', KEYS[1], 1, member)
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return 0
+`)
+
+// cappedIncr 在 pipeline 中执行 cappedIncrScript，超出上限的新成员计入 other
+func cappedIncr(ctx context.Context, pipe redis.Pipeliner, key, member, other string) {
+	cappedIncrScript.Eval(ctx, pipe, []string{key}, member, dimensionCap, other, int64(bucketExpiration.Seconds()))
+}
+
+// cacheRecordHit 记录一次浏览：文章和全站的 PV、UV、来源、推广参数，以及当天有浏览的文章
+func cacheRecordHit(ctx context.Context, rdb *redis.Client, hit *Hit, host string, hourly bool) error {
+	pipe := rdb.Pipeline()
+
+	buckets := []string{hit.At.Format(dayLayout)}
+	if hourly {
+		buckets = append(buckets, hit.At.Format(hourLayout))
+	}
+	for _, bucket := range buckets {
+		for _, id := range []int{hit.ArticleID, SiteID} {
+			pipe.Incr(ctx, AnalyticsPVKey(bucket, id))
+			pipe.PFAdd(ctx, AnalyticsUVKey(bucket, id), hit.Visitor)
+			pipe.Expire(ctx, AnalyticsPVKey(bucket, id), bucketExpiration)
+			pipe.Expire(ctx, AnalyticsUVKey(bucket, id), bucketExpiration)
+		}
+		pipe.SAdd(ctx, AnalyticsActiveKey(bucket), hit.ArticleID, SiteID)
+		pipe.Expire(ctx, AnalyticsActiveKey(bucket), bucketExpiration)
+	}
+
+	day := buckets[0]
+	utm, tagged := hit.UTM.normalize()
+	for _, id := range []int{hit.ArticleID, SiteID} {
+		cappedIncr(ctx, pipe, AnalyticsReferrerKey(day, id), host, DimensionOther)
+		if tagged {
+			cappedIncr(ctx, pipe, AnalyticsCampaignKey(day, id), utm.member(), utmOther.member())
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheRecordBotHit 记录一次爬虫浏览：文章和全站的爬虫 PV，以及当天各识别信号的次数
+func cacheRecordBotHit(ctx context.Context, rdb *redis.Client, hit *Hit, hourly bool) error {
+	pipe := rdb.Pipeline()
+
+	buckets := []string{hit.At.Format(dayLayout)}
+	if hourly {
+		buckets = append(buckets, hit.At.Format(hourLayout))
+	}
+	for _, bucket := range buckets {
+		for _, id := range []int{hit.ArticleID, SiteID} {
+			pipe.Incr(ctx, AnalyticsBotPVKey(bucket, id))
+			pipe.Expire(ctx, AnalyticsBotPVKey(bucket, id), bucketExpiration)
+		}
+		pipe.SAdd(ctx, AnalyticsActiveKey(bucket), hit.ArticleID, SiteID)
+		pipe.Expire(ctx, AnalyticsActiveKey(bucket), bucketExpiration)
+	}
+
+	pipe.HIncrBy(ctx, AnalyticsBotSignalKey(buckets[0]), hit.Bot, 1)
+	pipe.Expire(ctx, AnalyticsBotSignalKey(buckets[0]), bucketExpiration)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheGetActiveIDs 某个分桶内有浏览的文章ID，包含全站 0
+func cacheGetActiveIDs(ctx context.Context, rdb *redis.Client, bucket string) ([]int, error) {
+	members, err := rdb.SMembers(ctx, AnalyticsActiveKey(bucket)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// cacheGetCounts 获取分桶内各文章的 PV、UV 和爬虫 PV
+func cacheGetCounts(ctx context.Context, rdb *redis.Client, bucket string, ids []int) ([]Point, error) {
+	pipe := rdb.Pipeline()
+	pvs := make([]*redis.StringCmd, len(ids))
+	uvs := make([]*redis.IntCmd, len(ids))
+	bots := make([]*redis.StringCmd, len(ids))
+	for i, id := range ids {
+		pvs[i] = pipe.Get(ctx, AnalyticsPVKey(bucket, id))
+		uvs[i] = pipe.PFCount(ctx, AnalyticsUVKey(bucket, id))
+		bots[i] = pipe.Get(ctx, AnalyticsBotPVKey(bucket, id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("缓存获取异常 %w", err)
+	}
+
+	points := make([]Point, len(ids))
+	for i := range ids {
+		pv, _ := pvs[i].Int64()
+		bot, _ := bots[i].Int64()
+		points[i] = Point{PV: pv, UV: uvs[i].Val(), BotPV: bot}
+	}
+	return points, nil
+}
+
+// cacheGetTop 按次数从高到低分页读取有序集合，保留前 limit 个成员，其余合并为 other 放在最后
+func cacheGetTop(ctx context.Context, rdb *redis.Client, key string, limit int, other string) ([]redis.Z, error) {
+	top := []redis.Z{}
+	var rest float64
+	for start := int64(0); ; start += rollupPageSize {
+		items, err := rdb.ZRevRangeWithScores(ctx, key, start, start+rollupPageSize-1).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if item.Member == other || len(top) >= limit {
+				rest += item.Score
+				continue
+			}
+			top = append(top, item)
+		}
+		if len(items) < rollupPageSize {
+			break
+		}
+	}
+
+	if rest > 0 {
+		top = append(top, redis.Z{Member: other, Score: rest})
+	}
+	return top, nil
+}
+
+// cacheGetReferrers 获取某天文章次数最多的来源，其余合并为 (other)
+func cacheGetReferrers(ctx context.Context, rdb *redis.Client, day string, id int) ([]ReferrerCount, error) {
+	items, err := cacheGetTop(ctx, rdb, AnalyticsReferrerKey(day, id), dimensionTopN, DimensionOther)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := make([]ReferrerCount, len(items))
+	for i, item := range items {
+		referrers[i] = ReferrerCount{Host: item.Member.(string), Count: int64(item.Score)}
+	}
+	return referrers, nil
+}
+
+// cacheGetCampaigns 获取某天文章次数最多的推广来源，其余合并为 (other)
+func cacheGetCampaigns(ctx context.Context, rdb *redis.Client, day string, id int) ([]CampaignCount, error) {
+	items, err := cacheGetTop(ctx, rdb, AnalyticsCampaignKey(day, id), dimensionTopN, utmOther.member())
+	if err != nil {
+		return nil, err
+	}
+
+	campaigns := make([]CampaignCount, len(items))
+	for i, item := range items {
+		campaigns[i] = CampaignCount{UTM: parseUTMMember(item.Member.(string)), Count: int64(item.Score)}
+	}
+	return campaigns, nil
+}
+
+// cacheGetBotSignals 获取某天各识别信号的爬虫浏览次数
+func cacheGetBotSignals(ctx context.Context, rdb *redis.Client, day string) ([]SignalCount, error) {
+	values, err := rdb.HGetAll(ctx, AnalyticsBotSignalKey(day)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]SignalCount, 0, len(values))
+	for signal, v := range values {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			signals = append(signals, SignalCount{Signal: signal, Count: n})
+		}
+	}
+	return signals, nil
+}
@@ -0,0 +1,239 @@
+package analytics
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// upsertBatchSize 每条 INSERT 语句最多写入的行数
+const upsertBatchSize = 500
+
+// repoUpsertDaily 写入每日统计，Redis 中的计数是当天的累计值，重复写入结果不变
+func repoUpsertDaily(db *gorm.DB, stats []DailyStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}, {Name: "article_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"pv", "uv", "bot_pv"}),
+		}).
+		CreateInBatches(&stats, upsertBatchSize).
+		Error
+}
+
+func repoUpsertHourly(db *gorm.DB, stats []HourlyStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "hour"}, {Name: "article_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"pv", "uv", "bot_pv"}),
+		}).
+		CreateInBatches(&stats, upsertBatchSize).
+		Error
+}
+
+func repoUpsertReferrers(db *gorm.DB, stats []ReferrerStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}, {Name: "article_id"}, {Name: "host"}},
+			DoUpdates: clause.AssignmentColumns([]string{"count"}),
+		}).
+		CreateInBatches(&stats, upsertBatchSize).
+		Error
+}
+
+func repoUpsertCampaigns(db *gorm.DB, stats []CampaignStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{
+				{Name: "date"}, {Name: "article_id"}, {Name: "source"}, {Name: "medium"}, {Name: "campaign"},
+			},
+			DoUpdates: clause.AssignmentColumns([]string{"count"}),
+		}).
+		CreateInBatches(&stats, upsertBatchSize).
+		Error
+}
+
+func repoUpsertBotStats(db *gorm.DB, stats []BotStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "date"}, {Name: "signal"}},
+			DoUpdates: clause.AssignmentColumns([]string{"count"}),
+		}).
+		CreateInBatches(&stats, upsertBatchSize).
+		Error
+}
+
+// repoGetDaily 获取文章在区间内的每日统计，按日期升序
+func repoGetDaily(db *gorm.DB, id int, r Range) ([]DailyStat, error) {
+	stats := []DailyStat{}
+
+	result := db.
+		Where("article_id = ? AND date BETWEEN ? AND ?", id, r.from(), r.to()).
+		Order("date").
+		Find(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// repoGetHourly 获取文章在 [from, to) 内的每小时统计，按时间升序
+func repoGetHourly(db *gorm.DB, id int, from, to time.Time) ([]HourlyStat, error) {
+	stats := []HourlyStat{}
+
+	result := db.
+		Where("article_id = ? AND hour >= ? AND hour < ?", id, from, to).
+		Order("hour").
+		Find(&stats)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return stats, nil
+}
+
+// repoGetReferrers 区间内来源排行
+func repoGetReferrers(db *gorm.DB, id int, r Range, limit int) ([]ReferrerCount, error) {
+	referrers := []ReferrerCount{}
+
+	result := db.
+		Model(&ReferrerStat{}).
+		Select("host, SUM(count) AS count").
+		Where("article_id = ? AND date BETWEEN ? AND ?", id, r.from(), r.to()).
+		Group("host").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&referrers)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return referrers, nil
+}
+
+// repoGetTopArticles 区间内浏览最多的文章
+func repoGetTopArticles(db *gorm.DB, r Range, limit int) ([]TopArticle, error) {
+	articles := []TopArticle{}
+
+	result := db.
+		Table("article_daily_stats AS s").
+		Select("s.article_id, COALESCE(a.title, '') AS title, SUM(s.pv) AS pv, SUM(s.uv) AS uv, SUM(s.bot_pv) AS bot_pv").
+		Joins("LEFT JOIN articles a ON a.id = s.article_id").
+		Where("s.article_id <> ? AND s.date BETWEEN ? AND ?", SiteID, r.from(), r.to()).
+		Group("s.article_id, a.title").
+		Order("pv DESC").
+		Limit(limit).
+		Scan(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return articles, nil
+}
+
+// repoGetBotTotals 区间内全站正常访客和爬虫的浏览次数
+func repoGetBotTotals(db *gorm.DB, r Range) (*BotSummary, error) {
+	summary := &BotSummary{}
+
+	result := db.
+		Model(&DailyStat{}).
+		Select("COALESCE(SUM(pv), 0) AS human, COALESCE(SUM(bot_pv), 0) AS bot").
+		Where("article_id = ? AND date BETWEEN ? AND ?", SiteID, r.from(), r.to()).
+		Scan(summary)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return summary, nil
+}
+
+// repoGetBotSignals 区间内各识别信号的爬虫浏览次数
+func repoGetBotSignals(db *gorm.DB, r Range) ([]SignalCount, error) {
+	signals := []SignalCount{}
+
+	result := db.
+		Model(&BotStat{}).
+		Select("signal, SUM(count) AS count").
+		Where("date BETWEEN ? AND ?", r.from(), r.to()).
+		Group("signal").
+		Order("count DESC").
+		Scan(&signals)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return signals, nil
+}
+
+// repoGetCampaigns 区间内文章（id 为 0 时为全站）的推广来源排行
+func repoGetCampaigns(db *gorm.DB, id int, r Range, limit int) ([]CampaignCount, error) {
+	campaigns := []CampaignCount{}
+
+	result := db.
+		Model(&CampaignStat{}).
+		Select("source, medium, campaign, SUM(count) AS count").
+		Where("article_id = ? AND date BETWEEN ? AND ?", id, r.from(), r.to()).
+		Group("source, medium, campaign").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&campaigns)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return campaigns, nil
+}
+
+// repoGetCampaignSources 区间内某个推广活动在全站的各来源和媒介
+func repoGetCampaignSources(db *gorm.DB, campaign string, r Range) ([]CampaignCount, error) {
+	sources := []CampaignCount{}
+
+	result := db.
+		Model(&CampaignStat{}).
+		Select("source, medium, campaign, SUM(count) AS count").
+		Where("article_id = ? AND campaign = ? AND date BETWEEN ? AND ?", SiteID, campaign, r.from(), r.to()).
+		Group("source, medium, campaign").
+		Order("count DESC").
+		Scan(&sources)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return sources, nil
+}
+
+// repoGetCampaignArticles 区间内某个推广活动带来浏览最多的文章
+func repoGetCampaignArticles(db *gorm.DB, campaign string, r Range, limit int) ([]CampaignArticle, error) {
+	articles := []CampaignArticle{}
+
+	result := db.
+		Table("article_campaign_stats AS s").
+		Select("s.article_id, COALESCE(a.title, '') AS title, SUM(s.count) AS count").
+		Joins("LEFT JOIN articles a ON a.id = s.article_id").
+		Where("s.article_id <> ? AND s.campaign = ? AND s.date BETWEEN ? AND ?", SiteID, campaign, r.from(), r.to()).
+		Group("s.article_id, a.title").
+		Order("count DESC").
+		Limit(limit).
+		Scan(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return articles, nil
+}
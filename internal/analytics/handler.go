@@ -0,0 +1,205 @@
+package analytics
+
+import (
+	"my_web/backend/internal/httpserver"
+	"my_web/backend/internal/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	httpserver.BaseHandler
+	service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+// 所有接口都支持 ?from=yyyy-mm-dd&to=yyyy-mm-dd，默认为最近 30 天
+func (h *Handler) RegisterRoutes(e *gin.Engine) {
+	admin := e.Group("/api/admin/analytics", middleware.JWTAuth(), middleware.RequirePermission(middleware.PermAnalyticsRead))
+	{
+		admin.GET("/site/trend", h.getSiteTrend)
+		admin.GET("/articles/top", h.getTopArticles)
+		admin.GET("/articles/:id/trend", h.getArticleTrend)
+		admin.GET("/referrers", h.getReferrers)
+		admin.GET("/bots", h.getBots)
+		admin.GET("/campaigns", h.getCampaigns)
+		admin.GET("/campaigns/:campaign", h.getCampaignReport)
+	}
+}
+
+// 全站趋势，?interval=day|hour
+func (h *Handler) getSiteTrend(ctx *gin.Context) {
+	h.trend(ctx, SiteID)
+}
+
+// 文章趋势，?interval=day|hour
+func (h *Handler) getArticleTrend(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil || id <= 0 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	h.trend(ctx, id)
+}
+
+func (h *Handler) trend(ctx *gin.Context, id int) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	interval := ctx.DefaultQuery("interval", IntervalDay)
+	if interval != IntervalDay && interval != IntervalHour {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
+	}
+
+	data, err := h.service.GetTrend(ctx.Request.Context(), id, r, interval)
+	if err == ErrInvalidRange {
+		h.Fail(ctx, httpserver.ErrDateRange, nil)
+		return
+	}
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 浏览最多的文章，?limit=
+func (h *Handler) getTopArticles(ctx *gin.Context) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit, ok := h.limit(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.GetTopArticles(ctx.Request.Context(), r, limit)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 来源排行，?articleId= 不传时为全站，?limit=
+func (h *Handler) getReferrers(ctx *gin.Context) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit, ok := h.limit(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.DefaultQuery("articleId", "0"))
+	if err != nil || id < 0 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetReferrers(ctx.Request.Context(), id, r, limit)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 推广来源排行，按 utm_source、utm_medium、utm_campaign 分组，?articleId= 不传时为全站，?limit=
+func (h *Handler) getCampaigns(ctx *gin.Context) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit, ok := h.limit(ctx)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(ctx.DefaultQuery("articleId", "0"))
+	if err != nil || id < 0 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetCampaigns(ctx.Request.Context(), id, r, limit)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 单个推广活动的来源分布和文章排行，?limit= 为文章数
+func (h *Handler) getCampaignReport(ctx *gin.Context) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	limit, ok := h.limit(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.GetCampaignReport(ctx.Request.Context(), ctx.Param("campaign"), r, limit)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 正常访客与爬虫的浏览次数
+func (h *Handler) getBots(ctx *gin.Context) {
+	r, ok := h.dateRange(ctx)
+	if !ok {
+		return
+	}
+
+	data, err := h.service.GetBots(ctx.Request.Context(), r)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+func (h *Handler) dateRange(ctx *gin.Context) (Range, bool) {
+	r, err := ParseRange(ctx.Query("from"), ctx.Query("to"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDateRange, nil)
+		return r, false
+	}
+	return r, true
+}
+
+func (h *Handler) limit(ctx *gin.Context) (int, bool) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 100 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return 0, false
+	}
+	return limit, true
+}
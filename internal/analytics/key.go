@@ -0,0 +1,40 @@
+package analytics
+
+import (
+	"fmt"
+)
+
+// AnalyticsPVKey 某天（或某小时）文章的浏览次数，bucket 为 20060102 或 2006010215，id 为 0 时为全站
+func AnalyticsPVKey(bucket string, id int) string {
+	return fmt.Sprintf("Analytics:PV:%s:%d", bucket, id)
+}
+
+// AnalyticsUVKey 某天（或某小时）文章访客的 HyperLogLog
+func AnalyticsUVKey(bucket string, id int) string {
+	return fmt.Sprintf("Analytics:UV:%s:%d", bucket, id)
+}
+
+// AnalyticsReferrerKey 某天文章来源的有序集合，host -> 次数
+func AnalyticsReferrerKey(day string, id int) string {
+	return fmt.Sprintf("Analytics:Referrer:%s:%d", day, id)
+}
+
+// AnalyticsCampaignKey 某天文章 UTM 推广来源的有序集合，"source\tmedium\tcampaign" -> 次数
+func AnalyticsCampaignKey(day string, id int) string {
+	return fmt.Sprintf("Analytics:Campaign:%s:%d", day, id)
+}
+
+// AnalyticsActiveKey 某天（或某小时）有浏览的文章ID集合，汇总时只处理这些文章
+func AnalyticsActiveKey(bucket string) string {
+	return fmt.Sprintf("Analytics:Active:%s", bucket)
+}
+
+// AnalyticsBotPVKey 某天（或某小时）文章的爬虫浏览次数
+func AnalyticsBotPVKey(bucket string, id int) string {
+	return fmt.Sprintf("Analytics:BotPV:%s:%d", bucket, id)
+}
+
+// AnalyticsBotSignalKey 某天全站各识别信号的爬虫浏览次数，hash signal -> 次数
+func AnalyticsBotSignalKey(day string) string {
+	return fmt.Sprintf("Analytics:BotSignal:%s", day)
+}
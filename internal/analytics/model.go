@@ -0,0 +1,268 @@
+package analytics
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SiteID 全站统计使用的文章ID
+const SiteID = 0
+
+const (
+	dayLayout  = "20060102"
+	hourLayout = "2006010215"
+	dateLayout = "2006-01-02"
+)
+
+const (
+	IntervalDay  = "day"
+	IntervalHour = "hour"
+)
+
+// 来源为空和站内跳转时使用的来源名
+const (
+	ReferrerDirect   = "(direct)"
+	ReferrerInternal = "(internal)"
+	UTMNone          = "(none)"
+	// DimensionOther 无效的来源，以及超出数量上限的来源合并为这一项
+	DimensionOther = "(other)"
+)
+
+// 来源等由访客决定取值的维度，限制每篇文章每天的取值个数，避免 Redis 和数据库被任意取值撑大
+const (
+	dimensionCap   = 200 // Redis 中最多记录的不同取值，之后的新取值计入 (other)
+	dimensionTopN  = 50  // 汇总到数据库时保留次数最多的取值，其余计入 (other)
+	rollupPageSize = 100 // 汇总时每次从 Redis 读取的条数
+)
+
+// utmPattern 合法的 UTM 参数值：小写字母、数字和少量分隔符
+var utmPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9 ._+-]*$`)
+
+// utmOther 不合法或超出数量上限的推广来源合并为这一项
+var utmOther = UTM{Source: DimensionOther, Medium: DimensionOther, Campaign: DimensionOther}
+
+// hostPattern 合法的主机名：字母、数字、连字符组成的标签，以点分隔
+var hostPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+
+// DailyStat 文章每日浏览统计，ArticleID 为 0 时为全站
+type DailyStat struct {
+	Date      time.Time `gorm:"primaryKey;type:date" json:"date"`
+	ArticleID int       `gorm:"primaryKey;autoIncrement:false" json:"articleId"`
+	PV        int64     `json:"pv"`
+	UV        int64     `json:"uv"`
+	BotPV     int64     `json:"botPv"` // 爬虫浏览次数，不计入 PV 和 UV
+}
+
+func (DailyStat) TableName() string {
+	return "article_daily_stats"
+}
+
+// HourlyStat 文章每小时浏览统计，开启 hourly 时记录
+type HourlyStat struct {
+	Hour      time.Time `gorm:"primaryKey" json:"hour"`
+	ArticleID int       `gorm:"primaryKey;autoIncrement:false" json:"articleId"`
+	PV        int64     `json:"pv"`
+	UV        int64     `json:"uv"`
+	BotPV     int64     `json:"botPv"`
+}
+
+func (HourlyStat) TableName() string {
+	return "article_hourly_stats"
+}
+
+// ReferrerStat 每日来源统计，ArticleID 为 0 时为全站
+type ReferrerStat struct {
+	Date      time.Time `gorm:"primaryKey;type:date" json:"date"`
+	ArticleID int       `gorm:"primaryKey;autoIncrement:false" json:"articleId"`
+	Host      string    `gorm:"primaryKey;size:255" json:"host"`
+	Count     int64     `json:"count"`
+}
+
+func (ReferrerStat) TableName() string {
+	return "article_referrer_stats"
+}
+
+// CampaignStat 每日 UTM 推广来源统计，ArticleID 为 0 时为全站
+type CampaignStat struct {
+	Date      time.Time `gorm:"primaryKey;type:date" json:"date"`
+	ArticleID int       `gorm:"primaryKey;autoIncrement:false" json:"articleId"`
+	Source    string    `gorm:"primaryKey;size:64" json:"source"`
+	Medium    string    `gorm:"primaryKey;size:64" json:"medium"`
+	Campaign  string    `gorm:"primaryKey;size:128" json:"campaign"`
+	Count     int64     `json:"count"`
+}
+
+func (CampaignStat) TableName() string {
+	return "article_campaign_stats"
+}
+
+// BotStat 全站每日按识别信号统计的爬虫浏览
+type BotStat struct {
+	Date   time.Time `gorm:"primaryKey;type:date" json:"date"`
+	Signal string    `gorm:"primaryKey;size:32" json:"signal"`
+	Count  int64     `json:"count"`
+}
+
+func (BotStat) TableName() string {
+	return "article_bot_stats"
+}
+
+// Hit 一次文章浏览
+type Hit struct {
+	ArticleID int
+	Visitor   string // 访客标识，用户ID或IP
+	Referrer  string // 来源页面地址
+	UTM       UTM
+	Bot       string // 爬虫识别信号，为空表示正常访客
+	At        time.Time
+}
+
+// UTM 链接上的推广参数
+type UTM struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+}
+
+// member 有序集合中的成员，三个字段以制表符分隔，normalize 后的字段不含制表符
+func (u UTM) member() string {
+	return u.Source + "\t" + u.Medium + "\t" + u.Campaign
+}
+
+func parseUTMMember(m string) UTM {
+	parts := strings.SplitN(m, "\t", 3)
+	for len(parts) < 3 {
+		parts = append(parts, UTMNone)
+	}
+	return UTM{Source: parts[0], Medium: parts[1], Campaign: parts[2]}
+}
+
+// normalize 转小写、去掉首尾空白并截断，三个参数都为空时返回 false；
+// 缺少的字段记为 (none)，含有 utmPattern 以外字符的字段记为 (other)
+func (u UTM) normalize() (UTM, bool) {
+	clean := func(v string, max int) string {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if len(v) > max {
+			v = strings.TrimSpace(v[:max])
+		}
+		if v == "" {
+			return UTMNone
+		}
+		if !utmPattern.MatchString(v) {
+			return DimensionOther
+		}
+		return v
+	}
+
+	if strings.TrimSpace(u.Source+u.Medium+u.Campaign) == "" {
+		return u, false
+	}
+	return UTM{Source: clean(u.Source, 64), Medium: clean(u.Medium, 64), Campaign: clean(u.Campaign, 128)}, true
+}
+
+// CampaignCount 推广来源及次数
+type CampaignCount struct {
+	UTM
+	Count int64 `json:"count"`
+}
+
+// CampaignArticle 某个推广活动带来的文章浏览
+type CampaignArticle struct {
+	ArticleID int    `json:"articleId"`
+	Title     string `json:"title"`
+	Count     int64  `json:"count"`
+}
+
+// CampaignReport 某个推广活动在区间内的表现
+type CampaignReport struct {
+	Campaign string            `json:"campaign"`
+	Total    int64             `json:"total"`
+	Sources  []CampaignCount   `json:"sources"`
+	Articles []CampaignArticle `json:"articles"`
+}
+
+// Point 趋势中的一个时间点
+type Point struct {
+	Time  time.Time `json:"time"`
+	PV    int64     `json:"pv"`
+	UV    int64     `json:"uv"`
+	BotPV int64     `json:"botPv"`
+}
+
+// SignalCount 爬虫识别信号及次数
+type SignalCount struct {
+	Signal string `json:"signal"`
+	Count  int64  `json:"count"`
+}
+
+// BotSummary 区间内正常访客与爬虫的浏览次数
+type BotSummary struct {
+	Human   int64         `json:"human"`
+	Bot     int64         `json:"bot"`
+	Signals []SignalCount `json:"signals"`
+}
+
+// ReferrerCount 来源及次数
+type ReferrerCount struct {
+	Host  string `json:"host"`
+	Count int64  `json:"count"`
+}
+
+// TopArticle 区间内浏览最多的文章，UV 为每日 UV 之和
+type TopArticle struct {
+	ArticleID int    `json:"articleId"`
+	Title     string `json:"title"`
+	PV        int64  `json:"pv"`
+	UV        int64  `json:"uv"`
+	BotPV     int64  `json:"botPv"`
+}
+
+// Range 查询的日期区间，包含首尾两天
+type Range struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r Range) from() string {
+	return r.From.Format(dateLayout)
+}
+
+func (r Range) to() string {
+	return r.To.Format(dateLayout)
+}
+
+// Days 区间内的每一天
+func (r Range) Days() []time.Time {
+	days := []time.Time{}
+	for d := r.From; !d.After(r.To); d = d.AddDate(0, 0, 1) {
+		days = append(days, d)
+	}
+	return days
+}
+
+// normalizeReferrer 取来源地址的主机名，去掉端口和 www. 前缀；站内地址记为 (internal)，不合法的主机名记为 (other)
+func normalizeReferrer(raw string, internal string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ReferrerDirect
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return ReferrerDirect
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if internal != "" && host == internal {
+		return ReferrerInternal
+	}
+	if len(host) > 253 || !hostPattern.MatchString(host) {
+		return DimensionOther
+	}
+	return host
+}
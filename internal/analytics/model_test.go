@@ -0,0 +1,162 @@
+package analytics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestNormalizeReferrer(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"空", "  ", ReferrerDirect},
+		{"完整地址", "https://www.Google.com:443/search?q=x", "google.com"},
+		{"只有主机名", "news.ycombinator.com", "news.ycombinator.com"},
+		{"站内", "https://blog.example.com/a/1", ReferrerInternal},
+		{"非法字符", "http://evil_host!.com/", DimensionOther},
+		{"百分号编码", "http://%E4%BE%8B%E5%AD%90.com/", DimensionOther},
+		{"标签过长", "http://" + strings.Repeat("a", 64) + ".com/", DimensionOther},
+		{"主机名过长", "http://" + strings.Repeat("abcdefghi.", 26) + "com/", DimensionOther},
+		{"无法解析", "http://[::1", ReferrerDirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeReferrer(tt.raw, "blog.example.com"); got != tt.want {
+				t.Errorf("normalizeReferrer(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUTMNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     UTM
+		want   UTM
+		tagged bool
+	}{
+		{"全部为空", UTM{Source: " ", Medium: "", Campaign: ""}, UTM{}, false},
+		{"正常", UTM{Source: "Newsletter", Medium: " email ", Campaign: "spring_sale-2026"}, UTM{Source: "newsletter", Medium: "email", Campaign: "spring_sale-2026"}, true},
+		{"缺少字段", UTM{Source: "twitter"}, UTM{Source: "twitter", Medium: UTMNone, Campaign: UTMNone}, true},
+		{"制表符", UTM{Source: "a\tb", Medium: "x"}, UTM{Source: DimensionOther, Medium: "x", Campaign: UTMNone}, true},
+		{"非法字符", UTM{Source: "<script>", Medium: "中文", Campaign: "a/b"}, UTM{Source: DimensionOther, Medium: DimensionOther, Campaign: DimensionOther}, true},
+		{"过长截断", UTM{Source: strings.Repeat("s", 100), Campaign: strings.Repeat("c", 200)}, UTM{Source: strings.Repeat("s", 64), Medium: UTMNone, Campaign: strings.Repeat("c", 128)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, tagged := tt.in.normalize()
+			if tagged != tt.tagged {
+				t.Fatalf("tagged = %v, want %v", tagged, tt.tagged)
+			}
+			if tagged && got != tt.want {
+				t.Errorf("normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCampaignOther(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	key := AnalyticsCampaignKey("20261018", 1)
+
+	pipe := rdb.Pipeline()
+	for i := range dimensionCap + 5 {
+		u := UTM{Source: "s" + strconv.Itoa(i), Medium: "m", Campaign: "c"}
+		cappedIncr(ctx, pipe, key, u.member(), utmOther.member())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	campaigns, err := cacheGetCampaigns(ctx, rdb, "20261018", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campaigns) != dimensionTopN+1 {
+		t.Fatalf("len = %d, want %d", len(campaigns), dimensionTopN+1)
+	}
+	last := campaigns[len(campaigns)-1]
+	if last.UTM != utmOther || last.Count != int64(dimensionCap+5-dimensionTopN) {
+		t.Errorf("last = %+v, want %+v x%d", last, utmOther, dimensionCap+5-dimensionTopN)
+	}
+}
+
+func TestCappedIncrAndTop(t *testing.T) {
+	ctx := context.Background()
+	rdb := newTestRedis(t)
+	key := "test:referrers"
+
+	incr := func(member string, n int) {
+		t.Helper()
+		pipe := rdb.Pipeline()
+		for range n {
+			cappedIncr(ctx, pipe, key, member, DimensionOther)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 前 dimensionCap 个取值各记一次，第一个再多记一些
+	for i := range dimensionCap {
+		incr("host"+strconv.Itoa(i)+".com", 1)
+	}
+	incr("host0.com", 9)
+	// 超出上限的新取值计入 (other)，已有取值照常累加
+	incr("late.com", 3)
+	incr("host1.com", 1)
+
+	if n := rdb.ZCard(ctx, key).Val(); n != dimensionCap+1 {
+		t.Fatalf("ZCARD = %d, want %d", n, dimensionCap+1)
+	}
+	if s := rdb.ZScore(ctx, key, DimensionOther).Val(); s != 3 {
+		t.Fatalf("(other) = %v, want 3", s)
+	}
+	if rdb.TTL(ctx, key).Val() <= 0 {
+		t.Fatal("key has no expiration")
+	}
+
+	top, err := cacheGetTop(ctx, rdb, key, dimensionTopN, DimensionOther)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(top) != dimensionTopN+1 {
+		t.Fatalf("len(top) = %d, want %d", len(top), dimensionTopN+1)
+	}
+	if top[0].Member != "host0.com" || top[0].Score != 10 {
+		t.Errorf("top[0] = %v, want host0.com 10", top[0])
+	}
+	if top[1].Member != "host1.com" || top[1].Score != 2 {
+		t.Errorf("top[1] = %v, want host1.com 2", top[1])
+	}
+
+	// 总数不变：(other) = 原有的 3 + 未进前 N 的取值
+	var total, want float64
+	for _, z := range top {
+		total += z.Score
+	}
+	want = 10 + 2 + float64(dimensionCap-2) + 3
+	if total != want {
+		t.Errorf("total = %v, want %v", total, want)
+	}
+	last := top[len(top)-1]
+	if last.Member != DimensionOther || last.Score != float64(dimensionCap-dimensionTopN)+3 {
+		t.Errorf("last = %v, want (other) %d", last, dimensionCap-dimensionTopN+3)
+	}
+}
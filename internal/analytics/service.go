@@ -0,0 +1,341 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"my_web/backend/internal/bot"
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/utils"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRange = errors.New("invalid date range")
+)
+
+const (
+	// 查询区间最长天数
+	maxRangeDays = 366
+	// 小时趋势最长天数
+	maxHourlyDays = 7
+)
+
+type Service struct {
+	DB  *gorm.DB
+	RDB *redis.Client
+
+	conf *config.AnalyticsConfig
+	// 站点自身的主机名，来源为站内页面时记为 (internal)
+	internalHost string
+	// 爬虫识别，未开启时为 nil
+	detector *bot.Detector
+
+	task utils.TaskRunner
+}
+
+func NewAnalyticsService(ctx context.Context, db *gorm.DB, rdb *redis.Client, conf *config.AnalyticsConfig, site *config.SiteConfig) *Service {
+	service := &Service{
+		DB:   db,
+		RDB:  rdb,
+		conf: conf,
+	}
+	if u, err := url.Parse(site.URL); err == nil {
+		service.internalHost = strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	}
+	if conf.Bot.Enabled {
+		service.detector = bot.NewDetector(rdb, bot.Rules{
+			Crawlers:   conf.Bot.Crawlers,
+			Patterns:   conf.Bot.Patterns,
+			Headless:   conf.Bot.Headless,
+			RateLimit:  conf.Bot.RateLimit,
+			RateWindow: conf.Bot.RateWindow,
+		})
+	}
+
+	interval := conf.RollupInterval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	service.task = *utils.NewTaskRunner(
+		service,
+		utils.WithInterval(interval),
+		utils.WithTimeout(interval),
+	)
+	service.task.Start(ctx)
+
+	return service
+}
+
+// Classify 识别请求是否来自爬虫，未开启识别时总是返回正常访客
+func (s *Service) Classify(ctx context.Context, r *bot.Request) bot.Result {
+	if s.detector == nil {
+		return bot.Result{}
+	}
+	return s.detector.Classify(ctx, r)
+}
+
+// RecordView 记录一次文章浏览，爬虫浏览按配置单独计数或忽略
+func (s *Service) RecordView(ctx context.Context, hit *Hit) error {
+	if hit.At.IsZero() {
+		hit.At = time.Now()
+	}
+	if hit.Bot != "" {
+		if !s.conf.Bot.CountBots {
+			return nil
+		}
+		return cacheRecordBotHit(ctx, s.RDB, hit, s.conf.Hourly)
+	}
+	host := normalizeReferrer(hit.Referrer, s.internalHost)
+	return cacheRecordHit(ctx, s.RDB, hit, host, s.conf.Hourly)
+}
+
+// Run 将今天和昨天的分桶数据汇总到数据库
+// Redis 中保存的是分桶内的累计值，每次汇总都覆盖写入，重复执行结果不变
+func (s *Service) Run(ctx context.Context) {
+	now := time.Now()
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if err := s.rollupDay(ctx, day); err != nil {
+			log.Printf("汇总每日浏览统计失败 day=%s: %v", day.Format(dateLayout), err)
+		}
+
+		if !s.conf.Hourly {
+			continue
+		}
+		start := truncateDay(day)
+		for h := 0; h < 24; h++ {
+			hour := start.Add(time.Duration(h) * time.Hour)
+			if hour.After(now) {
+				break
+			}
+			if err := s.rollupHour(ctx, hour); err != nil {
+				log.Printf("汇总每小时浏览统计失败 hour=%s: %v", hour.Format(hourLayout), err)
+			}
+		}
+	}
+}
+
+func (s *Service) rollupDay(ctx context.Context, day time.Time) error {
+	bucket := day.Format(dayLayout)
+	ids, err := cacheGetActiveIDs(ctx, s.RDB, bucket)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	counts, err := cacheGetCounts(ctx, s.RDB, bucket, ids)
+	if err != nil {
+		return err
+	}
+
+	date := truncateDay(day)
+	stats := make([]DailyStat, len(ids))
+	referrers := []ReferrerStat{}
+	campaigns := []CampaignStat{}
+	for i, id := range ids {
+		stats[i] = DailyStat{Date: date, ArticleID: id, PV: counts[i].PV, UV: counts[i].UV, BotPV: counts[i].BotPV}
+
+		list, err := cacheGetReferrers(ctx, s.RDB, bucket, id)
+		if err != nil {
+			return err
+		}
+		for _, r := range list {
+			referrers = append(referrers, ReferrerStat{Date: date, ArticleID: id, Host: r.Host, Count: r.Count})
+		}
+
+		tagged, err := cacheGetCampaigns(ctx, s.RDB, bucket, id)
+		if err != nil {
+			return err
+		}
+		for _, c := range tagged {
+			campaigns = append(campaigns, CampaignStat{
+				Date:      date,
+				ArticleID: id,
+				Source:    c.Source,
+				Medium:    c.Medium,
+				Campaign:  c.Campaign,
+				Count:     c.Count,
+			})
+		}
+	}
+
+	signals, err := cacheGetBotSignals(ctx, s.RDB, bucket)
+	if err != nil {
+		return err
+	}
+	bots := make([]BotStat, len(signals))
+	for i, sc := range signals {
+		bots[i] = BotStat{Date: date, Signal: sc.Signal, Count: sc.Count}
+	}
+
+	return s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repoUpsertDaily(tx, stats); err != nil {
+			return err
+		}
+		if err := repoUpsertBotStats(tx, bots); err != nil {
+			return err
+		}
+		if err := repoUpsertCampaigns(tx, campaigns); err != nil {
+			return err
+		}
+		return repoUpsertReferrers(tx, referrers)
+	})
+}
+
+func (s *Service) rollupHour(ctx context.Context, hour time.Time) error {
+	bucket := hour.Format(hourLayout)
+	ids, err := cacheGetActiveIDs(ctx, s.RDB, bucket)
+	if err != nil || len(ids) == 0 {
+		return err
+	}
+
+	counts, err := cacheGetCounts(ctx, s.RDB, bucket, ids)
+	if err != nil {
+		return err
+	}
+
+	stats := make([]HourlyStat, len(ids))
+	for i, id := range ids {
+		stats[i] = HourlyStat{Hour: hour, ArticleID: id, PV: counts[i].PV, UV: counts[i].UV, BotPV: counts[i].BotPV}
+	}
+	return repoUpsertHourly(s.DB.WithContext(ctx), stats)
+}
+
+// 文章（id 为 0 时为全站）在区间内的浏览趋势，没有数据的时间点补 0
+func (s *Service) GetTrend(ctx context.Context, id int, r Range, interval string) ([]Point, error) {
+	if interval == IntervalHour {
+		return s.getHourlyTrend(ctx, id, r)
+	}
+
+	stats, err := repoGetDaily(s.DB.WithContext(ctx), id, r)
+	if err != nil {
+		return nil, err
+	}
+
+	byDate := make(map[string]DailyStat, len(stats))
+	for _, st := range stats {
+		byDate[st.Date.Format(dateLayout)] = st
+	}
+
+	days := r.Days()
+	points := make([]Point, len(days))
+	for i, d := range days {
+		st := byDate[d.Format(dateLayout)]
+		points[i] = Point{Time: d, PV: st.PV, UV: st.UV, BotPV: st.BotPV}
+	}
+	return points, nil
+}
+
+func (s *Service) getHourlyTrend(ctx context.Context, id int, r Range) ([]Point, error) {
+	if len(r.Days()) > maxHourlyDays {
+		return nil, ErrInvalidRange
+	}
+
+	from, to := r.From, r.To.AddDate(0, 0, 1)
+	stats, err := repoGetHourly(s.DB.WithContext(ctx), id, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byHour := make(map[int64]HourlyStat, len(stats))
+	for _, st := range stats {
+		byHour[st.Hour.Unix()] = st
+	}
+
+	points := []Point{}
+	for h := from; h.Before(to); h = h.Add(time.Hour) {
+		st := byHour[h.Unix()]
+		points = append(points, Point{Time: h, PV: st.PV, UV: st.UV, BotPV: st.BotPV})
+	}
+	return points, nil
+}
+
+// 区间内文章（id 为 0 时为全站）的来源排行
+func (s *Service) GetReferrers(ctx context.Context, id int, r Range, limit int) ([]ReferrerCount, error) {
+	return repoGetReferrers(s.DB.WithContext(ctx), id, r, limit)
+}
+
+// 区间内文章（id 为 0 时为全站）的推广来源排行
+func (s *Service) GetCampaigns(ctx context.Context, id int, r Range, limit int) ([]CampaignCount, error) {
+	return repoGetCampaigns(s.DB.WithContext(ctx), id, r, limit)
+}
+
+// 区间内某个推广活动的来源分布和带来浏览最多的文章
+func (s *Service) GetCampaignReport(ctx context.Context, campaign string, r Range, limit int) (*CampaignReport, error) {
+	utm, _ := UTM{Campaign: campaign}.normalize()
+	report := &CampaignReport{Campaign: utm.Campaign}
+
+	db := s.DB.WithContext(ctx)
+	var err error
+	report.Sources, err = repoGetCampaignSources(db, report.Campaign, r)
+	if err != nil {
+		return nil, err
+	}
+	for _, src := range report.Sources {
+		report.Total += src.Count
+	}
+
+	report.Articles, err = repoGetCampaignArticles(db, report.Campaign, r, limit)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// 区间内浏览最多的文章
+func (s *Service) GetTopArticles(ctx context.Context, r Range, limit int) ([]TopArticle, error) {
+	return repoGetTopArticles(s.DB.WithContext(ctx), r, limit)
+}
+
+// 区间内全站正常访客与爬虫的浏览次数，及爬虫按识别信号的分布
+func (s *Service) GetBots(ctx context.Context, r Range) (*BotSummary, error) {
+	db := s.DB.WithContext(ctx)
+	summary, err := repoGetBotTotals(db, r)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Signals, err = repoGetBotSignals(db, r)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// ParseRange 解析 yyyy-mm-dd 格式的起止日期，默认为最近 30 天
+func ParseRange(from, to string) (Range, error) {
+	var r Range
+	today := truncateDay(time.Now())
+
+	r.To = today
+	if to != "" {
+		t, err := time.ParseInLocation(dateLayout, to, time.Local)
+		if err != nil {
+			return r, ErrInvalidRange
+		}
+		r.To = t
+	}
+
+	r.From = r.To.AddDate(0, 0, -29)
+	if from != "" {
+		t, err := time.ParseInLocation(dateLayout, from, time.Local)
+		if err != nil {
+			return r, ErrInvalidRange
+		}
+		r.From = t
+	}
+
+	if r.From.After(r.To) || r.To.Sub(r.From) > maxRangeDays*24*time.Hour {
+		return r, ErrInvalidRange
+	}
+	return r, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
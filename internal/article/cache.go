@@ -2,10 +2,12 @@ package article
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"my_web/backend/internal/cache"
+	"my_web/backend/internal/config"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -17,60 +19,242 @@ var (
 
 const (
 	articleCacheExpiration = 60 * time.Minute
+	// sitemap 由定时任务主动重建，过期时间远大于重建间隔，保证爬虫总能命中缓存
+	sitemapCacheExpiration = 24 * time.Hour
+	// 热门排行随时间衰减，列表缓存时间较短
+	popularCacheExpiration = 5 * time.Minute
+	// 检索结果只随所含文章失效，新发布的文章要等缓存过期后才能被检索到
+	searchCacheExpiration = 5 * time.Minute
+	// 不存在的文章ID的缓存时间，避免反复查询数据库
+	missingCacheExpiration = 30 * time.Second
+
+	// 过期时间随机浮动的比例，避免同一时刻写入的缓存同时过期
+	cacheJitter = 0.1
+	// 提前刷新的激进程度，1 为 XFetch 算法的推荐值
+	cacheBeta = 1.0
 )
 
-func cacheGetArticlesByPage(ctx context.Context, rdb *redis.Client, page, pageSize int) ([]ArticleWithoutContent, int, error) {
-	// 获取文章列表
-	key := ArticleByPageKey(page, pageSize)
-	data, err := rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return nil, 0, ErrCacheMiss
+// 各查询缓存的参数
+type (
+	pageQuery struct {
+		page, pageSize int
 	}
-	if err != nil {
-		return nil, 0, fmt.Errorf("缓存获取异常 %w", err)
+	popularQuery struct {
+		window string
+		limit  int
+	}
+	tagQuery struct {
+		name           string
+		page, pageSize int
+	}
+	feedQuery struct {
+		format, tag string
 	}
+	searchQuery struct {
+		q              string
+		page, pageSize int
+	}
+)
+
+// articlePage 一页文章及总数
+type articlePage struct {
+	Articles []ArticleWithoutContent `json:"articles"`
+	Total    int                     `json:"total"`
+}
 
-	var articles []ArticleWithoutContent
-	if err = json.Unmarshal([]byte(data), &articles); err != nil {
-		return nil, 0, fmt.Errorf("反序列化失败 %w", err)
+// searchPage 一页检索结果及总数
+type searchPage struct {
+	Articles []SearchArticle `json:"articles"`
+	Total    int             `json:"total"`
+}
+
+// caches 文章模块的查询缓存，每个查询在这里声明 key、过期时间和序列化方式
+type caches struct {
+	byID     *cache.Cache[int, *Article]
+	bySlug   *cache.Cache[string, int]
+	pages    *cache.Cache[pageQuery, articlePage]
+	popular  *cache.Cache[popularQuery, []ArticleWithoutContent]
+	tagPages *cache.Cache[tagQuery, articlePage]
+	tags     *cache.Cache[struct{}, []TagWithCount]
+	feeds    *cache.Cache[feedQuery, *FeedBody]
+	search   *cache.Cache[searchQuery, searchPage]
+}
+
+func newCaches(rdb *redis.Client, conf *config.CacheConfig, metrics cache.Metrics) caches {
+	ttl := cache.FixedTTL(articleCacheExpiration)
+	l1 := func(name string) cache.L1Config {
+		c := conf.Local(name)
+		return cache.L1Config{Size: c.Size, TTL: c.TTL}
 	}
 
-	// 获取总数
-	totalData, err := rdb.Get(ctx, ArticleTotalKey()).Result()
-	if err == redis.Nil {
-		return articles, 0, ErrCacheMiss // 列表有但总数未命中
+	return caches{
+		// 只缓存公开文章；不存在的ID短时间内直接返回 ErrArticleNotFound
+		byID: cache.New(rdb, cache.Config[int, *Article]{
+			Name:        "article",
+			Key:         ArticleByIDKey,
+			TTL:         ttl,
+			Codec:       cache.Compressed(cache.Msgpack, 4096),
+			Jitter:      cacheJitter,
+			Beta:        cacheBeta,
+			Negative:    ErrArticleNotFound,
+			NegativeTTL: missingCacheExpiration,
+			Cacheable:   func(a *Article) bool { return a.Status == ArticlePublic },
+			L1:          l1("article"),
+			Metrics:     metrics,
+		}),
+		bySlug: cache.New(rdb, cache.Config[string, int]{
+			Name:    "slug",
+			Key:     ArticleBySlugKey,
+			TTL:     ttl,
+			Jitter:  cacheJitter,
+			Groups:  func(_ string, id int) []string { return []string{ArticleGroup(id)} },
+			L1:      l1("slug"),
+			Metrics: metrics,
+		}),
+		pages: cache.New(rdb, cache.Config[pageQuery, articlePage]{
+			Name:    "page",
+			Key:     func(q pageQuery) string { return ArticleByPageKey(q.page, q.pageSize) },
+			TTL:     ttl,
+			Jitter:  cacheJitter,
+			Beta:    cacheBeta,
+			Groups:  func(_ pageQuery, p articlePage) []string { return summaryGroups(p.Articles, ArticleListGroup()) },
+			L1:      l1("page"),
+			Metrics: metrics,
+		}),
+		popular: cache.New(rdb, cache.Config[popularQuery, []ArticleWithoutContent]{
+			Name:    "popular",
+			Key:     func(q popularQuery) string { return ArticleByPopularKey(q.window, q.limit) },
+			TTL:     cache.FixedTTL(popularCacheExpiration),
+			Jitter:  cacheJitter,
+			Beta:    cacheBeta,
+			Groups:  func(_ popularQuery, l []ArticleWithoutContent) []string { return summaryGroups(l, ArticleListGroup()) },
+			L1:      l1("popular"),
+			Metrics: metrics,
+		}),
+		tagPages: cache.New(rdb, cache.Config[tagQuery, articlePage]{
+			Name:    "tagPage",
+			Key:     func(q tagQuery) string { return ArticleByTagKey(q.name, q.page, q.pageSize) },
+			TTL:     ttl,
+			Jitter:  cacheJitter,
+			Beta:    cacheBeta,
+			Groups:  func(q tagQuery, p articlePage) []string { return summaryGroups(p.Articles, ArticleTagGroup(q.name)) },
+			L1:      l1("tagPage"),
+			Metrics: metrics,
+		}),
+		tags: cache.New(rdb, cache.Config[struct{}, []TagWithCount]{
+			Name:    "tags",
+			Key:     func(struct{}) string { return ArticleTagsKey() },
+			TTL:     ttl,
+			Jitter:  cacheJitter,
+			Groups:  func(struct{}, []TagWithCount) []string { return []string{ArticleTagsGroup()} },
+			L1:      l1("tags"),
+			Metrics: metrics,
+		}),
+		feeds: cache.New(rdb, cache.Config[feedQuery, *FeedBody]{
+			Name:    "feed",
+			Key:     func(q feedQuery) string { return ArticleFeedKey(q.format, q.tag) },
+			TTL:     ttl,
+			Codec:   cache.Compressed(cache.Msgpack, 1024),
+			Jitter:  cacheJitter,
+			Groups:  feedGroups,
+			L1:      l1("feed"),
+			Metrics: metrics,
+		}),
+		search: cache.New(rdb, cache.Config[searchQuery, searchPage]{
+			Name:    "search",
+			Key:     func(q searchQuery) string { return ArticleSearchKey(q.q, q.page, q.pageSize) },
+			TTL:     cache.FixedTTL(searchCacheExpiration),
+			Jitter:  cacheJitter,
+			Groups:  searchGroups,
+			L1:      l1("search"),
+			Metrics: metrics,
+		}),
 	}
-	if err != nil {
-		return nil, 0, fmt.Errorf("获取总数失败 %w", err)
+}
+
+// evict 删除本实例进程内缓存中的文章详情及属于任一分组的列表
+func (c *caches) evict(id int, groups []string) {
+	c.byID.Evict(id)
+	c.bySlug.EvictGroups(groups...)
+	c.pages.EvictGroups(groups...)
+	c.popular.EvictGroups(groups...)
+	c.tagPages.EvictGroups(groups...)
+	c.tags.EvictGroups(groups...)
+	c.feeds.EvictGroups(groups...)
+	c.search.EvictGroups(groups...)
+}
+
+// summaryGroups 列表缓存属于 base 分组及其中每篇文章的分组
+func summaryGroups(articles []ArticleWithoutContent, base string) []string {
+	groups := make([]string, 0, len(articles)+1)
+	groups = append(groups, base)
+	for _, a := range articles {
+		groups = append(groups, ArticleGroup(a.ID))
 	}
+	return groups
+}
 
-	total, err := strconv.Atoi(totalData)
-	if err != nil {
-		return nil, 0, fmt.Errorf("总数解析失败 %w", err)
+// feedGroups 全站订阅源随公开文章集合失效，标签订阅源随该标签失效
+func feedGroups(q feedQuery, f *FeedBody) []string {
+	base := ArticleListGroup()
+	if q.tag != "" {
+		base = ArticleTagGroup(q.tag)
+	}
+	groups := make([]string, 0, len(f.ArticleIDs)+1)
+	groups = append(groups, base)
+	for _, id := range f.ArticleIDs {
+		groups = append(groups, ArticleGroup(id))
 	}
+	return groups
+}
 
-	return articles, total, nil
+// searchGroups 搜索结果随公开文章集合失效；每个查询条件一个 key，
+// 不加入单篇文章的分组，内容修改后在 searchCacheExpiration 内过期
+func searchGroups(searchQuery, searchPage) []string {
+	return []string{ArticleListGroup()}
 }
 
-func cacheSetArticlesByPage(ctx context.Context, rdb *redis.Client, page, pageSize int, articles []ArticleWithoutContent, total int) error {
-	// 序列化文章列表
-	data, err := json.Marshal(articles)
+// cacheGetSitemap part 为 0 时获取 /sitemap.xml，否则获取对应分片
+func cacheGetSitemap(ctx context.Context, rdb *redis.Client, part int) ([]byte, error) {
+	key := ArticleSitemapKey()
+	if part > 0 {
+		key = ArticleSitemapPartKey(part)
+	}
+
+	data, err := rdb.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	}
 	if err != nil {
-		return fmt.Errorf("序列化失败 %w", err)
+		return nil, fmt.Errorf("缓存获取异常 %w", err)
 	}
 
-	// 使用 Pipeline 批量设置
-	pipe := rdb.Pipeline()
-	pipe.Set(ctx, ArticleByPageKey(page, pageSize), data, articleCacheExpiration)
-	pipe.Set(ctx, ArticleTotalKey(), strconv.Itoa(total), articleCacheExpiration)
+	return data, nil
+}
+
+// cacheSetSitemap 一次写入 sitemap 及全部分片，并删除上次生成的多余分片
+func cacheSetSitemap(ctx context.Context, rdb *redis.Client, root []byte, parts [][]byte) error {
+	prev, err := rdb.Get(ctx, ArticleSitemapPartsKey()).Int()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	pipe := rdb.TxPipeline()
+	for i, part := range parts {
+		pipe.Set(ctx, ArticleSitemapPartKey(i+1), part, sitemapCacheExpiration)
+	}
+	for i := len(parts) + 1; i <= prev; i++ {
+		pipe.Del(ctx, ArticleSitemapPartKey(i))
+	}
+	pipe.Set(ctx, ArticleSitemapPartsKey(), len(parts), 0)
+	pipe.Set(ctx, ArticleSitemapKey(), root, sitemapCacheExpiration)
 
 	_, err = pipe.Exec(ctx)
 	return err
 }
 
-func cacheGetArticleByID(ctx context.Context, rdb *redis.Client, id int) (*Article, error) {
-	key := ArticleByIDKey(id)
-	data, err := rdb.Get(ctx, key).Result()
+func cacheGetRobots(ctx context.Context, rdb *redis.Client) ([]byte, error) {
+	data, err := rdb.Get(ctx, ArticleRobotsKey()).Bytes()
 	if err == redis.Nil {
 		return nil, ErrCacheMiss
 	}
@@ -78,69 +262,449 @@ func cacheGetArticleByID(ctx context.Context, rdb *redis.Client, id int) (*Artic
 		return nil, fmt.Errorf("缓存获取异常 %w", err)
 	}
 
-	var article Article
-	if err := json.Unmarshal([]byte(data), &article); err != nil {
-		return nil, fmt.Errorf("反序列化失败 %w", err)
-	}
+	return data, nil
+}
 
-	return &article, nil
+func cacheSetRobots(ctx context.Context, rdb *redis.Client, data []byte) error {
+	return rdb.Set(ctx, ArticleRobotsKey(), data, sitemapCacheExpiration).Err()
 }
 
-func cacheSetArticleByID(ctx context.Context, rdb *redis.Client, id int, article *Article) error {
-	data, err := json.Marshal(article)
-	if err != nil {
-		return fmt.Errorf("序列化失败 %w", err)
+// setMemberScript 读者加入或移出集合，集合发生变化时才标记文章待同步
+// KEYS[1] 点赞/回应集合，KEYS[2] 待同步集合；ARGV[1] 读者标识，ARGV[2] 1 为加入，ARGV[3] 文章ID
+var setMemberScript = redis.NewScript(`
+local n
+if ARGV[2] == '1' then
+	n = redis.call('SADD', KEYS[1], ARGV[1])
+else
+	n = redis.call('SREM', KEYS[1], ARGV[1])
+end
+if n == 1 then
+	redis.call('SADD', KEYS[2], ARGV[3])
+end
+return n
+`)
+
+// reactionKey 点赞或某表情回应对应的集合
+func reactionKey(id int, kind string) string {
+	if kind == reactionLike {
+		return ArticleLikeKey(id)
 	}
+	return ArticleReactionKey(id, kind)
+}
 
-	err = rdb.Set(ctx, ArticleByIDKey(id), data, articleCacheExpiration).Err()
+// cacheSetMember 读者加入或移出点赞/回应集合，返回集合是否发生变化，有变化时标记文章待同步
+func cacheSetMember(ctx context.Context, rdb *redis.Client, id int, key, member string, add bool) (bool, error) {
+	flag := "0"
+	if add {
+		flag = "1"
+	}
+	n, err := setMemberScript.Run(ctx, rdb, []string{key, ArticleReactionDirtyKey()}, member, flag, id).Int()
 	if err != nil {
-		return err
+		return false, err
 	}
+	return n > 0, nil
+}
 
-	return nil
+// cacheReactionsLoaded 文章的点赞和回应集合是否已与数据库对齐
+func cacheReactionsLoaded(ctx context.Context, rdb *redis.Client, id int) (bool, error) {
+	n, err := rdb.Exists(ctx, ArticleReactionLoadedKey(id)).Result()
+	return n > 0, err
 }
 
-func cacheGetArticlesByPopular(ctx context.Context, rdb *redis.Client, limit int) ([]ArticleWithoutContent, error) {
-	key := ArticleByPopularKey(limit)
+// cacheGetReactionMembers 获取 Redis 中文章的全部点赞和回应
+func cacheGetReactionMembers(ctx context.Context, rdb *redis.Client, id int, kinds []string) ([]Reaction, error) {
+	kinds = append([]string{reactionLike}, kinds...)
+	pipe := rdb.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(kinds))
+	for i, kind := range kinds {
+		cmds[i] = pipe.SMembers(ctx, reactionKey(id, kind))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
 
-	data, err := rdb.Get(ctx, key).Result()
-	if err == redis.Nil {
-		return nil, ErrCacheMiss
+	reactions := []Reaction{}
+	for i, kind := range kinds {
+		for _, m := range cmds[i].Val() {
+			reactions = append(reactions, Reaction{ArticleID: id, Kind: kind, Member: m})
+		}
 	}
-	if err != nil {
+	return reactions, nil
+}
+
+// cacheLoadReactions 将数据库中的点赞和回应并入 Redis 集合，并标记已对齐
+func cacheLoadReactions(ctx context.Context, rdb *redis.Client, id int, reactions []Reaction) error {
+	pipe := rdb.TxPipeline()
+	for _, r := range reactions {
+		pipe.SAdd(ctx, reactionKey(id, r.Kind), r.Member)
+	}
+	pipe.Set(ctx, ArticleReactionLoadedKey(id), 1, 0)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheGetReactions 获取文章的点赞数和各表情回应数，member 不为空时同时返回该读者的状态
+func cacheGetReactions(ctx context.Context, rdb *redis.Client, id int, kinds []string, member string) (*ReactionSummary, error) {
+	pipe := rdb.Pipeline()
+	likes := pipe.SCard(ctx, ArticleLikeKey(id))
+	counts := make([]*redis.IntCmd, len(kinds))
+	for i, kind := range kinds {
+		counts[i] = pipe.SCard(ctx, ArticleReactionKey(id, kind))
+	}
+
+	var liked *redis.BoolCmd
+	mine := make([]*redis.BoolCmd, len(kinds))
+	if member != "" {
+		liked = pipe.SIsMember(ctx, ArticleLikeKey(id), member)
+		for i, kind := range kinds {
+			mine[i] = pipe.SIsMember(ctx, ArticleReactionKey(id, kind), member)
+		}
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
 		return nil, fmt.Errorf("缓存获取异常 %w", err)
 	}
 
-	var articles []ArticleWithoutContent
-	if err := json.Unmarshal([]byte(data), &articles); err != nil {
-		return nil, fmt.Errorf("反序列化失败 %w", err)
+	summary := &ReactionSummary{
+		Likes:     int(likes.Val()),
+		Reactions: ReactionCounts{},
+		Mine:      []string{},
+	}
+	for i, kind := range kinds {
+		if n := counts[i].Val(); n > 0 {
+			summary.Reactions[kind] = int(n)
+		}
+	}
+	if member != "" {
+		summary.Liked = liked.Val()
+		for i, kind := range kinds {
+			if mine[i].Val() {
+				summary.Mine = append(summary.Mine, kind)
+			}
+		}
+	}
+
+	return summary, nil
+}
+
+// cacheTakeReactionDirtyIDs 取出待同步的文章ID
+// 在事务中将待同步集合并入同步中集合并清空，同步期间新的变化会写入新的待同步集合
+// 上次同步失败遗留在同步中集合的文章会一并重试
+func cacheTakeReactionDirtyIDs(ctx context.Context, rdb *redis.Client) ([]int, error) {
+	pipe := rdb.TxPipeline()
+	pipe.SUnionStore(ctx, ArticleReactionFlushingKey(), ArticleReactionFlushingKey(), ArticleReactionDirtyKey())
+	pipe.Del(ctx, ArticleReactionDirtyKey())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	members, err := rdb.SMembers(ctx, ArticleReactionFlushingKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// cacheDoneReactionFlush 同步成功的文章移出同步中集合
+func cacheDoneReactionFlush(ctx context.Context, rdb *redis.Client, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	members := make([]any, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	return rdb.SRem(ctx, ArticleReactionFlushingKey(), members...).Err()
+}
+
+// cacheAddViewUV 记录一次浏览，同时把文章加入待同步集合，返回是否为新访客
+func cacheAddViewUV(ctx context.Context, rdb *redis.Client, id int, userID string) (bool, error) {
+	pipe := rdb.TxPipeline()
+	added := pipe.PFAdd(ctx, ArticleViewKey(id), userID)
+	pipe.SAdd(ctx, ArticleActiveViewIDsKey(), id)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return added.Val() > 0, nil
+}
+
+// viewRotateScript 原子地把各文章的 UV 转存到批次中并清空，转存后的新浏览计入新的 UV
+// KEYS[1] 待同步文章集合，KEYS[2] 批次 hash，KEYS[3] 批次集合，KEYS[4..] 各文章的 UV；
+// ARGV[1] 批次ID，ARGV[2..] 与 KEYS[4..] 对应的文章ID
+var viewRotateScript = redis.NewScript(`
+local n = 0
+for i = 4, #KEYS do
+	local id = ARGV[i - 2]
+	local count = redis.call('PFCOUNT', KEYS[i])
+	if count > 0 then
+		redis.call('HSET', KEYS[2], id, count)
+		n = n + 1
+	end
+	redis.call('DEL', KEYS[i])
+	redis.call('SREM', KEYS[1], id)
+end
+if n > 0 then
+	redis.call('SADD', KEYS[3], ARGV[1])
+end
+return n
+`)
+
+// cacheMigrateLegacyViews 旧版本只写 UV 不写待同步集合，把遗留的 UV 所属文章补入待同步集合，只执行一次
+// 返回补入的文章数，失败时清除标记以便下次重试
+func cacheMigrateLegacyViews(ctx context.Context, rdb *redis.Client) (n int, err error) {
+	ok, err := rdb.SetNX(ctx, ArticleViewMigratedKey(), 1, 0).Result()
+	if err != nil || !ok {
+		return 0, err
 	}
+	defer func() {
+		if err != nil {
+			rdb.Del(ctx, ArticleViewMigratedKey())
+		}
+	}()
 
-	return articles, nil
+	prefix := strings.TrimSuffix(ArticleViewKey(-1), "*")
+	iter := rdb.Scan(ctx, 0, ArticleViewKey(-1), 100).Iterator()
+	for iter.Next(ctx) {
+		id, err := strconv.Atoi(strings.TrimPrefix(iter.Val(), prefix))
+		if err != nil {
+			continue
+		}
+		if err := rdb.SAdd(ctx, ArticleActiveViewIDsKey(), id).Err(); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, iter.Err()
 }
 
-func cacheSetArticlesByPopular(ctx context.Context, rdb *redis.Client, limit int, articles []ArticleWithoutContent) error {
-	data, err := json.Marshal(articles)
+func cacheGetActiveViewIDs(ctx context.Context, rdb *redis.Client) ([]int, error) {
+	members, err := rdb.SMembers(ctx, ArticleActiveViewIDsKey()).Result()
 	if err != nil {
-		return fmt.Errorf("序列化失败 %w", err)
+		return nil, err
 	}
 
-	err = rdb.Set(ctx, ArticleByPopularKey(limit), data, articleCacheExpiration).Err()
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// cacheRotateViews 将一组文章的 UV 转存为批次
+func cacheRotateViews(ctx context.Context, rdb *redis.Client, batch string, ids []int) error {
+	keys := make([]string, 0, len(ids)+3)
+	keys = append(keys, ArticleActiveViewIDsKey(), ArticleViewBatchKey(batch), ArticleViewBatchesKey())
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, batch)
+	for _, id := range ids {
+		keys = append(keys, ArticleViewKey(id))
+		args = append(args, id)
+	}
+	return viewRotateScript.Run(ctx, rdb, keys, args...).Err()
+}
+
+func cacheGetViewBatches(ctx context.Context, rdb *redis.Client) ([]string, error) {
+	return rdb.SMembers(ctx, ArticleViewBatchesKey()).Result()
+}
+
+func cacheGetViewBatch(ctx context.Context, rdb *redis.Client, batch string) (map[int]int64, error) {
+	values, err := rdb.HGetAll(ctx, ArticleViewBatchKey(batch)).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	increments := make(map[int]int64, len(values))
+	for k, v := range values {
+		id, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		increments[id] = n
+	}
+	return increments, nil
 }
 
-func cacheAddViewUV(ctx context.Context, rdb *redis.Client, id int, userID string) error {
-	return rdb.PFAdd(ctx, ArticleViewKey(id), userID).Err()
+// cacheDoneViewBatch 批次写入数据库后删除
+func cacheDoneViewBatch(ctx context.Context, rdb *redis.Client, batch string) error {
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, ArticleViewBatchKey(batch))
+	pipe.SRem(ctx, ArticleViewBatchesKey(), batch)
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-func cacheGetViewUV(ctx context.Context, rdb *redis.Client, id int) (int64, error) {
-	return rdb.PFCount(ctx, ArticleViewKey(id)).Result()
+// rankIncrScript 按前向衰减给各窗口的排行加分：分数 = 权重 × 2^((事件时间 - 纪元) / 半衰期)
+// 所有文章使用同一纪元，读取时无需再按当前时间衰减，分数可直接比较；
+// 权重为负时按原事件时间扣分，与加分时相互抵消，分数最低为 0
+// KEYS[1] 纪元 hash，KEYS[2..] 各窗口的排行；ARGV[1] 文章ID，ARGV[2] 权重，ARGV[3] 事件时间，ARGV[4] 当前时间，
+// ARGV[5..] 与 KEYS[2..] 对应的半衰期秒数，0 表示不衰减
+var rankIncrScript = redis.NewScript(`
+local member, weight, at, now = ARGV[1], tonumber(ARGV[2]), tonumber(ARGV[3]), tonumber(ARGV[4])
+for i = 2, #KEYS do
+	local hl = tonumber(ARGV[i + 3])
+	local w = weight
+	if hl > 0 then
+		local epoch = tonumber(redis.call('HGET', KEYS[1], KEYS[i]))
+		if not epoch then
+			epoch = now
+			redis.call('HSET', KEYS[1], KEYS[i], epoch)
+		end
+		w = w * math.pow(2, (at - epoch) / hl)
+	end
+	if tonumber(redis.call('ZINCRBY', KEYS[i], w, member)) < 0 then
+		redis.call('ZADD', KEYS[i], 0, member)
+	end
+end
+return 0
+`)
+
+// rankSwapScript 用重建好的临时排行替换各窗口的排行，并迁移纪元、写入重建标记
+// KEYS[1] 纪元 hash，KEYS[2] 重建标记，KEYS[3..] 依次为临时排行和对应的排行
+var rankSwapScript = redis.NewScript(`
+for i = 3, #KEYS, 2 do
+	local tmp, live = KEYS[i], KEYS[i + 1]
+	if redis.call('EXISTS', tmp) == 1 then
+		redis.call('RENAME', tmp, live)
+	else
+		redis.call('DEL', live)
+	end
+	local epoch = redis.call('HGET', KEYS[1], tmp)
+	if epoch then
+		redis.call('HSET', KEYS[1], live, epoch)
+		redis.call('HDEL', KEYS[1], tmp)
+	else
+		redis.call('HDEL', KEYS[1], live)
+	end
+end
+redis.call('SET', KEYS[2], 1)
+return 0
+`)
+
+// rankRescaleScript 纪元距今超过 ARGV[3] 个半衰期时，将分数整体缩放到以当前时间为纪元，避免浮点溢出
+// KEYS[1] 纪元 hash，KEYS[2] 排行；ARGV[1] 当前时间，ARGV[2] 半衰期秒数，ARGV[3] 最大半衰期数
+var rankRescaleScript = redis.NewScript(`
+local now, hl, limit = tonumber(ARGV[1]), tonumber(ARGV[2]), tonumber(ARGV[3])
+local epoch = tonumber(redis.call('HGET', KEYS[1], KEYS[2]))
+if not epoch or (now - epoch) / hl < limit then
+	return 0
+end
+local factor = math.pow(2, (epoch - now) / hl)
+local items = redis.call('ZRANGE', KEYS[2], 0, -1, 'WITHSCORES')
+for i = 1, #items, 2 do
+	redis.call('ZADD', KEYS[2], tonumber(items[i + 1]) * factor, items[i])
+end
+redis.call('HSET', KEYS[1], KEYS[2], now)
+return #items / 2
+`)
+
+// cacheRankIncr halfLives 与 windows 一一对应
+func cacheRankIncr(ctx context.Context, rdb *redis.Client, id int, weight float64, at time.Time, windows []string, halfLives []time.Duration) error {
+	return rankIncr(ctx, rdb, ArticleRankKey, id, weight, at, windows, halfLives)
 }
 
-func cacheDelViewUV(ctx context.Context, rdb *redis.Client, id int) error {
-	return rdb.Del(ctx, ArticleViewKey(id)).Err()
+// cacheRankRebuildIncr 给重建中的临时排行加分
+func cacheRankRebuildIncr(ctx context.Context, rdb *redis.Client, id int, weight float64, at time.Time, windows []string, halfLives []time.Duration) error {
+	return rankIncr(ctx, rdb, ArticleRankRebuildKey, id, weight, at, windows, halfLives)
+}
+
+func rankIncr(ctx context.Context, rdb *redis.Client, key func(string) string, id int, weight float64, at time.Time, windows []string, halfLives []time.Duration) error {
+	keys := make([]string, 0, len(windows)+1)
+	args := make([]any, 0, len(windows)+4)
+	keys = append(keys, ArticleRankEpochKey())
+	args = append(args, id, weight, at.Unix(), time.Now().Unix())
+	for i, w := range windows {
+		keys = append(keys, key(w))
+		args = append(args, int64(halfLives[i].Seconds()))
+	}
+
+	return rankIncrScript.Run(ctx, rdb, keys, args...).Err()
+}
+
+// cacheRankBuilt 排行是否已从数据库重建过
+func cacheRankBuilt(ctx context.Context, rdb *redis.Client) (bool, error) {
+	n, err := rdb.Exists(ctx, ArticleRankBuiltKey()).Result()
+	return n > 0, err
+}
+
+// cacheRankLock 抢占重建锁，ttl 内其他实例不会再重建；锁不主动释放，重建失败时等过期后重试
+func cacheRankLock(ctx context.Context, rdb *redis.Client, ttl time.Duration) (bool, error) {
+	return rdb.SetNX(ctx, ArticleRankLockKey(), 1, ttl).Result()
+}
+
+// cacheRankRebuildReset 清除上次失败的重建留下的临时排行
+func cacheRankRebuildReset(ctx context.Context, rdb *redis.Client, windows []string) error {
+	keys := make([]string, len(windows))
+	for i, w := range windows {
+		keys[i] = ArticleRankRebuildKey(w)
+	}
+
+	pipe := rdb.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.HDel(ctx, ArticleRankEpochKey(), keys...)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheRankSwap 用临时排行替换各窗口的排行
+func cacheRankSwap(ctx context.Context, rdb *redis.Client, windows []string) error {
+	keys := make([]string, 0, 2+2*len(windows))
+	keys = append(keys, ArticleRankEpochKey(), ArticleRankBuiltKey())
+	for _, w := range windows {
+		keys = append(keys, ArticleRankRebuildKey(w), ArticleRankKey(w))
+	}
+	return rankSwapScript.Run(ctx, rdb, keys).Err()
+}
+
+// cacheRankFirstLike 读者第一次点赞时返回 true，之后取消再点赞返回 false
+func cacheRankFirstLike(ctx context.Context, rdb *redis.Client, id int, member string) (bool, error) {
+	n, err := rdb.SAdd(ctx, ArticleRankLikedKey(id), member).Result()
+	return n > 0, err
+}
+
+// cacheRankRescale 返回被缩放的文章数，无需缩放时为 0
+func cacheRankRescale(ctx context.Context, rdb *redis.Client, window string, halfLife time.Duration, maxHalfLives int) (int64, error) {
+	keys := []string{ArticleRankEpochKey(), ArticleRankKey(window)}
+	return rankRescaleScript.Run(ctx, rdb, keys, time.Now().Unix(), int64(halfLife.Seconds()), maxHalfLives).Int64()
+}
+
+func cacheRankRemove(ctx context.Context, rdb *redis.Client, id int, windows []string) error {
+	pipe := rdb.Pipeline()
+	for _, w := range windows {
+		pipe.ZRem(ctx, ArticleRankKey(w), id)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheRankTop 按分数从高到低取前 n 篇文章ID，排行不存在时返回 ErrCacheMiss
+func cacheRankTop(ctx context.Context, rdb *redis.Client, window string, n int) ([]int, error) {
+	members, err := rdb.ZRevRange(ctx, ArticleRankKey(window), 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("缓存获取异常 %w", err)
+	}
+	if len(members) == 0 {
+		return nil, ErrCacheMiss
+	}
+
+	ids := make([]int, 0, len(members))
+	for _, m := range members {
+		if id, err := strconv.Atoi(m); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
 }
@@ -1,22 +1,29 @@
 package article
 
 import (
+	"html"
+	"my_web/backend/internal/markdown"
+	"strconv"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-func repoGetAllArticleIDs(db *gorm.DB) ([]int, error) {
-	ids := []int{}
-
-	result := db.
-		Model(&Article{}).
-		Select("id").
-		Where("is_delete = false AND status = ?", ArticlePublic).
-		Pluck("id", &ids)
+// articleSummaryFields ArticleWithoutContent 对应的列
+var articleSummaryFields = []string{
+	"id", "created_at", "updated_at", "title", "slug", "author_name", "views", "tags", "cover",
+	"word_count", "char_count", "reading_time", "comment_count", "likes", "reactions",
+}
 
-	if result.Error != nil {
-		return nil, nil
+// summaryColumns 生成 ArticleWithoutContent 的查询列，prefix 为表名前缀如 "articles."
+func summaryColumns(prefix string) string {
+	cols := make([]string, len(articleSummaryFields))
+	for i, f := range articleSummaryFields {
+		cols[i] = prefix + f
 	}
-	return ids, nil
+	return strings.Join(cols, ", ")
 }
 
 // repoGetArticlesByPage
@@ -44,11 +51,11 @@ func repoGetArticlesByPage(db *gorm.DB, page, pageSize int) ([]ArticleWithoutCon
 	return articles, int(total), nil
 }
 
-// repoGetArticleByID
+// repoGetArticleByID 获取未删除的文章，不区分状态
 func repoGetArticleByID(db *gorm.DB, id int) (*Article, error) {
 	var article Article
 
-	result := db.First(&article, id)
+	result := db.Where("is_delete = false").First(&article, id)
 	if result.Error != nil {
 		return &article, result.Error
 	}
@@ -78,7 +85,7 @@ func repoGetArticlesByPopular(db *gorm.DB, limit int) ([]ArticleWithoutContent,
 		Model(&Article{}).
 		Where("is_delete = false AND status = ?", ArticlePublic).
 		Order("views DESC").
-		Select("id, created_at, updated_at, title, author_name, views, tags, cover").
+		Select(summaryColumns("")).
 		Limit(limit).
 		Find(&articles)
 	if result.Error != nil {
@@ -88,32 +95,643 @@ func repoGetArticlesByPopular(db *gorm.DB, limit int) ([]ArticleWithoutContent,
 	return articles, nil
 }
 
-// repoIncrementViews 增加文章的 views
-func repoIncrementViews(db *gorm.DB, id int, increment int64) error {
+// repoGetSummariesByIDs 按ID获取公开文章摘要，顺序与 ids 无关
+func repoGetSummariesByIDs(db *gorm.DB, ids []int) ([]ArticleWithoutContent, error) {
+	var articles []ArticleWithoutContent
+
 	result := db.
 		Model(&Article{}).
-		Where("id = ?", id).
-		UpdateColumn("views", gorm.Expr("views + ?", increment))
+		Where("id IN ? AND is_delete = false AND status = ?", ids, ArticlePublic).
+		Select(summaryColumns("")).
+		Find(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return articles, nil
+}
+
+// repoGetRankArticles 获取全部公开文章的排行计分字段
+func repoGetRankArticles(db *gorm.DB) ([]Article, error) {
+	articles := []Article{}
+
+	result := db.
+		Model(&Article{}).
+		Select("id, created_at, publish_at, views, likes, comment_count").
+		Where("is_delete = false AND status = ?", ArticlePublic).
+		Find(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
 
-	return result.Error
+	return articles, nil
 }
 
-// repoBatchUpdateViews 批量更新文章的 views
+// viewBatchRows 单条 UPDATE 语句最多更新的文章数，避免超出参数个数上限
+const viewBatchRows = 1000
+
+// repoBatchUpdateViews 批量增加文章的 views，每 viewBatchRows 篇文章一条语句
 func repoBatchUpdateViews(db *gorm.DB, viewsMap map[int]int64) error {
 	if len(viewsMap) == 0 {
 		return nil
 	}
 
-	db.Transaction(func(tx *gorm.DB) error {
-		for id, increment := range viewsMap {
-			if err := tx.Model(&Article{}).
-				Where("id = ?", id).
-				UpdateColumn("views", gorm.Expr("views + ?", increment)).Error; err != nil {
+	rows := make([]string, 0, viewBatchRows)
+	args := make([]any, 0, viewBatchRows*2)
+	flush := func() error {
+		sql := "UPDATE articles SET views = articles.views + v.n FROM (VALUES " +
+			strings.Join(rows, ", ") +
+			") AS v(id, n) WHERE articles.id = v.id"
+		err := db.Exec(sql, args...).Error
+		rows, args = rows[:0], args[:0]
+		return err
+	}
+
+	for id, increment := range viewsMap {
+		rows = append(rows, "(?::bigint, ?::bigint)")
+		args = append(args, id, increment)
+		if len(rows) == viewBatchRows {
+			if err := flush(); err != nil {
 				return err
 			}
 		}
-		return nil
+	}
+	if len(rows) > 0 {
+		return flush()
+	}
+	return nil
+}
+
+// repoApplyViewBatch 在事务中写入批次记录并累加浏览量，批次已写入过时直接返回
+func repoApplyViewBatch(db *gorm.DB, batch string, viewsMap map[int]int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&ViewBatch{BatchID: batch})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		return repoBatchUpdateViews(tx, viewsMap)
 	})
+}
+
+// repoPruneViewBatches 清理过早的批次记录，pending 中的批次仍在 Redis 中等待清除，其记录保留
+func repoPruneViewBatches(db *gorm.DB, before time.Time, pending []string) error {
+	query := db.Where("created_at < ?", before)
+	if len(pending) > 0 {
+		query = query.Where("batch_id NOT IN ?", pending)
+	}
+	return query.Delete(&ViewBatch{}).Error
+}
+
+// repoCreateArticle 新建文章
+func repoCreateArticle(db *gorm.DB, article *Article) error {
+	return db.Create(article).Error
+}
+
+// repoUpdateArticle 按字段更新未删除的文章
+func repoUpdateArticle(db *gorm.DB, id int, updates map[string]any) error {
+	result := db.
+		Model(&Article{}).
+		Where("id = ? AND is_delete = false", id).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
 
 	return nil
 }
+
+// repoDeleteArticle 软删除文章
+func repoDeleteArticle(db *gorm.DB, id int) error {
+	result := db.
+		Model(&Article{}).
+		Where("id = ? AND is_delete = false", id).
+		UpdateColumn("is_delete", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// repoSyncArticleTags 按 article.Tags 字符串重建文章与标签的关联
+func repoSyncArticleTags(db *gorm.DB, article *Article) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tags := []Tag{}
+		for _, name := range splitTags(article.Tags) {
+			tag := Tag{Name: name}
+			if err := tx.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+				return err
+			}
+			tags = append(tags, tag)
+		}
+
+		return tx.Model(article).Association("TagList").Replace(tags)
+	})
+}
+
+// repoBackfillTags 为尚无标签关联的文章解析 Tags 字符串并建立关联
+func repoBackfillTags(db *gorm.DB) (int, error) {
+	var articles []*Article
+
+	err := db.
+		Select("id, tags").
+		Where("tags <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM article_tags WHERE article_tags.article_id = articles.id)").
+		Find(&articles).
+		Error
+	if err != nil {
+		return 0, err
+	}
+
+	for _, article := range articles {
+		if err := repoSyncArticleTags(db, article); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(articles), nil
+}
+
+// repoTagExists 标签是否存在
+func repoTagExists(db *gorm.DB, name string) (bool, error) {
+	var count int64
+
+	result := db.Model(&Tag{}).Where("name = ?", name).Limit(1).Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return count > 0, nil
+}
+
+// repoGetTags 获取所有标签及公开文章数
+func repoGetTags(db *gorm.DB) ([]TagWithCount, error) {
+	tags := []TagWithCount{}
+
+	result := db.
+		Table("tags").
+		Select("tags.name, COUNT(articles.id) AS count").
+		Joins("JOIN article_tags ON article_tags.tag_id = tags.id").
+		Joins("JOIN articles ON articles.id = article_tags.article_id").
+		Where("articles.is_delete = false AND articles.status = ?", ArticlePublic).
+		Group("tags.name").
+		Order("count DESC, tags.name").
+		Scan(&tags)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return tags, nil
+}
+
+// repoGetArticlesByTag 分页获取某标签下的公开文章
+func repoGetArticlesByTag(db *gorm.DB, name string, page, pageSize int) ([]ArticleWithoutContent, int, error) {
+	var articles []ArticleWithoutContent
+	var total int64
+
+	query := func() *gorm.DB {
+		return db.
+			Model(&Article{}).
+			Joins("JOIN article_tags ON article_tags.article_id = articles.id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.name = ?", name).
+			Where("articles.is_delete = false AND articles.status = ?", ArticlePublic)
+	}
+
+	result := query().Count(&total)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	result = query().
+		Select(summaryColumns("articles.")).
+		Order("articles.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&articles)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return articles, int(total), nil
+}
+
+// 全文检索使用 simple 配置，不做词干处理，中英文都按分隔符切词
+const searchConfig = "simple"
+
+// repoInitSearchIndex 创建全文检索的生成列和 GIN 索引
+// search_vector 为 STORED 生成列，文章增改时由 PostgreSQL 自动维护；
+// 索引为部分索引，只覆盖公开且未删除的文章
+func repoInitSearchIndex(db *gorm.DB) error {
+	stmts := []string{
+		`ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('` + searchConfig + `', coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('` + searchConfig + `', coalesce("desc", '')), 'B') ||
+				setweight(to_tsvector('` + searchConfig + `', coalesce(content, '')), 'C')
+			) STORED`,
+		`CREATE INDEX IF NOT EXISTS idx_articles_search_vector ON articles
+			USING GIN (search_vector)
+			WHERE is_delete = false AND status = ` + strconv.Itoa(ArticlePublic),
+	}
+
+	for _, stmt := range stmts {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ts_headline 的高亮标记使用控制字符，原文中的同名字符先被去掉，
+// 结果经 HTML 转义后再替换为 <mark>，正文中的 HTML 不会原样输出
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+var headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `", MaxWords=35, MinWords=15, MaxFragments=2`
+
+// highlightHTML 将 ts_headline 的结果转义并把标记替换为 <mark>
+func highlightHTML(headline string) string {
+	escaped := html.EscapeString(headline)
+	return strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>").Replace(escaped)
+}
+
+// repoSearchArticles 全文检索公开文章，按相关度排序，Highlight 为转义后的 HTML
+func repoSearchArticles(db *gorm.DB, q string, page, pageSize int) ([]SearchArticle, int, error) {
+	articles := []SearchArticle{}
+	var total int64
+
+	tsquery := "websearch_to_tsquery('" + searchConfig + "', ?)"
+
+	query := func() *gorm.DB {
+		return db.
+			Model(&Article{}).
+			Where("search_vector @@ "+tsquery, q).
+			Where("is_delete = false AND status = ?", ArticlePublic)
+	}
+
+	result := query().Count(&total)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	result = query().
+		Select(
+			summaryColumns("")+", "+
+				"ts_headline('"+searchConfig+"', "+
+				"translate(coalesce(\"desc\", '') || ' ' || coalesce(content, ''), chr(2) || chr(3), ''), "+
+				tsquery+", ?) AS highlight",
+			q, headlineOptions,
+		).
+		Order(gorm.Expr("ts_rank(search_vector, "+tsquery+") DESC, created_at DESC", q)).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Scan(&articles)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	for i := range articles {
+		articles[i].Highlight = highlightHTML(articles[i].Highlight)
+	}
+	return articles, int(total), nil
+}
+
+// repoGetDueScheduledIDs 获取已到发布时间的定时文章ID
+func repoGetDueScheduledIDs(db *gorm.DB, now time.Time) ([]int, error) {
+	ids := []int{}
+
+	result := db.
+		Model(&Article{}).
+		Where("is_delete = false AND status = ? AND publish_at <= ?", ArticleScheduled, now).
+		Pluck("id", &ids)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return ids, nil
+}
+
+// repoPublishScheduled 将定时文章转为公开，状态已被修改的文章不受影响；
+// 同时刷新 updated_at，使订阅源和站点地图的更新时间与发布时间一致
+func repoPublishScheduled(db *gorm.DB, id int) (bool, error) {
+	result := db.
+		Model(&Article{}).
+		Where("id = ? AND is_delete = false AND status = ?", id, ArticleScheduled).
+		UpdateColumns(map[string]any{"status": ArticlePublic, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func repoCreateRevision(db *gorm.DB, revision *ArticleRevision) error {
+	return db.Create(revision).Error
+}
+
+// repoGetRevisions 获取文章的修订列表（不含正文），新的在前
+func repoGetRevisions(db *gorm.DB, articleID int) ([]ArticleRevision, error) {
+	revisions := []ArticleRevision{}
+
+	result := db.
+		Select("id, article_id, created_at, title, \"desc\", tags").
+		Where("article_id = ?", articleID).
+		Order("id DESC").
+		Find(&revisions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return revisions, nil
+}
+
+func repoGetRevision(db *gorm.DB, articleID, id int) (*ArticleRevision, error) {
+	var revision ArticleRevision
+
+	result := db.Where("article_id = ?", articleID).First(&revision, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &revision, nil
+}
+
+// repoSlugTaken 判断 slug 是否已被其他文章使用（包括其他文章的曾用 slug）
+func repoSlugTaken(db *gorm.DB, slug string, excludeID int) (bool, error) {
+	var count int64
+
+	err := db.Model(&Article{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).
+		Error
+	if err != nil || count > 0 {
+		return count > 0, err
+	}
+
+	err = db.Model(&SlugHistory{}).
+		Where("slug = ? AND article_id <> ?", slug, excludeID).
+		Count(&count).
+		Error
+	return count > 0, err
+}
+
+// repoGetArticleIDBySlug 通过当前 slug 获取未删除文章的ID
+func repoGetArticleIDBySlug(db *gorm.DB, slug string) (int, error) {
+	var article Article
+
+	result := db.
+		Select("id").
+		Where("slug = ? AND is_delete = false", slug).
+		First(&article)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return article.ID, nil
+}
+
+// repoGetSlugRedirect 通过曾用 slug 获取文章当前地址
+func repoGetSlugRedirect(db *gorm.DB, slug string) (*SlugRedirect, error) {
+	var redirect SlugRedirect
+
+	result := db.
+		Table("slug_histories").
+		Select("articles.id, articles.slug, articles.status, articles.author_id").
+		Joins("JOIN articles ON articles.id = slug_histories.article_id").
+		Where("slug_histories.slug = ? AND articles.is_delete = false AND articles.slug <> ''", slug).
+		Take(&redirect)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &redirect, nil
+}
+
+// repoChangeSlug 修改文章 slug，旧 slug 记入历史
+func repoChangeSlug(db *gorm.DB, id int, oldSlug, newSlug string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if oldSlug != "" {
+			err := tx.Where("slug = ?", oldSlug).
+				Assign(SlugHistory{ArticleID: id}).
+				FirstOrCreate(&SlugHistory{Slug: oldSlug}).
+				Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// 改回曾用 slug 时移除对应历史
+		err := tx.Where("slug = ? AND article_id = ?", newSlug, id).
+			Delete(&SlugHistory{}).
+			Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&Article{}).
+			Where("id = ?", id).
+			UpdateColumn("slug", newSlug).
+			Error
+	})
+}
+
+// repoGetArticlesWithoutSlug 获取尚未生成 slug 的文章
+func repoGetArticlesWithoutSlug(db *gorm.DB) ([]*Article, error) {
+	var articles []*Article
+
+	err := db.
+		Select("id, title").
+		Where("slug = '' OR slug IS NULL").
+		Find(&articles).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// repoGetArticlesNotRendered 获取尚未渲染或由旧版本规则渲染的文章
+func repoGetArticlesNotRendered(db *gorm.DB) ([]*Article, error) {
+	var articles []*Article
+
+	err := db.
+		Select("id, content").
+		Where("content <> ''").
+		Where("render_version < ?", markdown.Version).
+		Find(&articles).
+		Error
+	if err != nil {
+		return nil, err
+	}
+
+	return articles, nil
+}
+
+// repoSetArticleColumns 更新字段，不修改 updated_at
+func repoSetArticleColumns(db *gorm.DB, id int, columns map[string]any) error {
+	return db.
+		Model(&Article{}).
+		Where("id = ?", id).
+		UpdateColumns(columns).
+		Error
+}
+
+// repoGetFeedArticles 获取最新的公开文章，tag 不为空时只取该标签下的文章
+func repoGetFeedArticles(db *gorm.DB, tag string, limit int) ([]Article, error) {
+	articles := []Article{}
+
+	query := db.
+		Model(&Article{}).
+		Where("articles.is_delete = false AND articles.status = ?", ArticlePublic)
+	if tag != "" {
+		query = query.
+			Joins("JOIN article_tags ON article_tags.article_id = articles.id").
+			Joins("JOIN tags ON tags.id = article_tags.tag_id").
+			Where("tags.name = ?", tag)
+	}
+
+	result := query.
+		Select("articles.*").
+		Order("COALESCE(articles.publish_at, articles.created_at) DESC").
+		Limit(limit).
+		Find(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return articles, nil
+}
+
+// repoGetSitemapArticles 获取全部公开文章的地址信息
+func repoGetSitemapArticles(db *gorm.DB) ([]Article, error) {
+	articles := []Article{}
+
+	result := db.
+		Model(&Article{}).
+		Select("id, slug, updated_at").
+		Where("is_delete = false AND status = ?", ArticlePublic).
+		Order("id").
+		Find(&articles)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return articles, nil
+}
+
+// repoAddBookmark 收藏文章，重复收藏不报错
+func repoAddBookmark(db *gorm.DB, userID, articleID int) error {
+	return db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Bookmark{UserID: userID, ArticleID: articleID}).
+		Error
+}
+
+func repoDeleteBookmark(db *gorm.DB, userID, articleID int) error {
+	return db.
+		Where("user_id = ? AND article_id = ?", userID, articleID).
+		Delete(&Bookmark{}).
+		Error
+}
+
+// repoSetReaction 添加或删除读者的点赞/回应，返回是否发生变化
+func repoSetReaction(db *gorm.DB, r *Reaction, add bool) (bool, error) {
+	var result *gorm.DB
+	if add {
+		result = db.Clauses(clause.OnConflict{DoNothing: true}).Create(r)
+	} else {
+		result = db.
+			Where("article_id = ? AND kind = ? AND member = ?", r.ArticleID, r.Kind, r.Member).
+			Delete(&Reaction{})
+	}
+	return result.RowsAffected > 0, result.Error
+}
+
+// repoAddReactions 批量添加点赞/回应，已存在的跳过
+func repoAddReactions(db *gorm.DB, reactions []Reaction) error {
+	if len(reactions) == 0 {
+		return nil
+	}
+	return db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(&reactions, 500).
+		Error
+}
+
+// repoGetReactions 获取文章的全部点赞和回应
+func repoGetReactions(db *gorm.DB, articleID int) ([]Reaction, error) {
+	reactions := []Reaction{}
+	result := db.
+		Select("article_id, kind, member").
+		Where("article_id = ?", articleID).
+		Find(&reactions)
+	return reactions, result.Error
+}
+
+// repoCountReactions 统计文章各类回应数，点赞的 Kind 为 like
+func repoCountReactions(db *gorm.DB, articleID int) (map[string]int, error) {
+	rows := []struct {
+		Kind  string
+		Count int
+	}{}
+	result := db.
+		Model(&Reaction{}).
+		Select("kind, COUNT(*) AS count").
+		Where("article_id = ?", articleID).
+		Group("kind").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, r := range rows {
+		counts[r.Kind] = r.Count
+	}
+	return counts, nil
+}
+
+// repoGetBookmarks 分页获取用户收藏的公开文章，最近收藏的在前
+func repoGetBookmarks(db *gorm.DB, userID, page, pageSize int) ([]ArticleWithoutContent, int, error) {
+	var articles []ArticleWithoutContent
+	var total int64
+
+	query := func() *gorm.DB {
+		return db.
+			Model(&Article{}).
+			Joins("JOIN bookmarks ON bookmarks.article_id = articles.id").
+			Where("bookmarks.user_id = ? AND articles.is_delete = false AND articles.status = ?", userID, ArticlePublic)
+	}
+
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query().
+		Select(summaryColumns("articles.")).
+		Order("bookmarks.created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&articles)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return articles, int(total), nil
+}
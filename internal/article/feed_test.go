@@ -0,0 +1,64 @@
+package article
+
+import (
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/feed"
+	"testing"
+	"time"
+)
+
+func TestRenderFeedETag(t *testing.T) {
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	build := func(content string) *feed.Feed {
+		return &feed.Feed{
+			Title:   "Blog",
+			Updated: at,
+			Items:   []feed.Item{{ID: "1", Title: "a", ContentHTML: content, Published: at, Updated: at}},
+		}
+	}
+
+	base, err := renderFeed(FeedRSS, build("<p>v1</p>"), []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		f      *feed.Feed
+		same   bool
+	}{
+		{"内容相同", FeedRSS, build("<p>v1</p>"), true},
+		{"只改正文，元数据不变", FeedRSS, build("<p>v2</p>"), false},
+		{"格式不同", FeedAtom, build("<p>v1</p>"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderFeed(tt.format, tt.f, []int{1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got.ETag == base.ETag) != tt.same {
+				t.Fatalf("ETag %s vs %s, want same = %v", got.ETag, base.ETag, tt.same)
+			}
+		})
+	}
+
+	if _, err := renderFeed("yaml", build(""), nil); err != ErrInvalidFeed {
+		t.Fatalf("err = %v, want ErrInvalidFeed", err)
+	}
+}
+
+func TestArticleGUID(t *testing.T) {
+	s := &Service{site: &config.SiteConfig{URL: "https://blog.example.com"}}
+	a := &Article{ID: 42, Slug: "old-slug", CreatedAt: time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)}
+
+	want := "tag:blog.example.com,2024-05-01:article:42"
+	if got := s.articleGUID(a); got != want {
+		t.Fatalf("guid = %s, want %s", got, want)
+	}
+	a.Slug = "new-slug"
+	if got := s.articleGUID(a); got != want {
+		t.Fatalf("改名后 guid 变化: %s", got)
+	}
+}
@@ -1,8 +1,15 @@
 package article
 
 import (
+	"my_web/backend/internal/analytics"
+	"my_web/backend/internal/bot"
 	"my_web/backend/internal/httpserver"
+	"my_web/backend/internal/middleware"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,11 +26,55 @@ func NewHandler(s *Service) *Handler {
 }
 
 func (h *Handler) RegisterRoutes(e *gin.Engine) {
-	r := e.Group("/api/article")
+	r := e.Group("/api/article", middleware.OptionalJWTAuth())
 	{
 		r.GET("", h.getArticles)
 		r.GET("/hotArticles", h.getHotArticles)
+		r.GET("/search", h.searchArticles)
+		r.GET("/tags", h.getTags)
+		r.GET("/tags/:name", h.getArticlesByTag)
+		r.GET("/preview/:token", h.getArticlePreview)
+		r.GET("/slug/:slug", h.getArticleBySlug)
 		r.GET("/:id", h.getArticleDetail)
+		r.GET("/:id/reactions", h.getReactions)
+		r.POST("/:id/like", h.like(true))
+		r.DELETE("/:id/like", h.like(false))
+		r.POST("/:id/reactions/:kind", h.react(true))
+		r.DELETE("/:id/reactions/:kind", h.react(false))
+	}
+
+	// 收藏需要登录
+	me := e.Group("/api", middleware.JWTAuth())
+	{
+		me.POST("/article/:id/bookmark", h.bookmark(true))
+		me.DELETE("/article/:id/bookmark", h.bookmark(false))
+		me.GET("/me/bookmarks", h.getBookmarks)
+	}
+
+	// 订阅源
+	e.GET("/feed.xml", h.getFeed(FeedRSS))
+	e.GET("/atom.xml", h.getFeed(FeedAtom))
+	e.GET("/feed.json", h.getFeed(FeedJSON))
+	e.GET("/tags/:name/feed.xml", h.getFeed(FeedRSS))
+	e.GET("/tags/:name/atom.xml", h.getFeed(FeedAtom))
+	e.GET("/tags/:name/feed.json", h.getFeed(FeedJSON))
+
+	// 搜索引擎
+	e.GET("/sitemap.xml", h.getSitemap)
+	e.GET("/sitemaps/:file", h.getSitemap)
+	e.GET("/robots.txt", h.getRobots)
+
+	admin := e.Group("/api/article", middleware.JWTAuth(), middleware.RequirePermission(middleware.PermArticleWrite))
+	{
+		admin.POST("", h.createArticle)
+		admin.PUT("/:id", h.updateArticle)
+		admin.PATCH("/:id", h.patchArticle)
+		admin.DELETE("/:id", middleware.RequirePermission(middleware.PermArticleDelete), h.deleteArticle)
+		admin.POST("/:id/share", h.shareArticle)
+		admin.GET("/:id/revisions", h.getRevisions)
+		admin.GET("/:id/revisions/diff", h.diffRevisions)
+		admin.GET("/:id/revisions/:rid", h.getRevision)
+		admin.POST("/:id/revisions/:rid/restore", h.restoreRevision)
 	}
 }
 
@@ -55,8 +106,96 @@ func (h *Handler) getArticles(ctx *gin.Context) {
 	})
 }
 
+// 全文检索文章
+func (h *Handler) searchArticles(ctx *gin.Context) {
+	q := strings.TrimSpace(ctx.Query("q"))
+	if q == "" {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 50 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	articles, total, err := h.service.SearchArticles(ctx.Request.Context(), q, page, pageSize)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, httpserver.PageResult[SearchArticle]{
+		Page:  page,
+		Size:  pageSize,
+		Total: total,
+		Data:  articles,
+	})
+}
+
+// 获取标签列表
+func (h *Handler) getTags(ctx *gin.Context) {
+	data, err := h.service.GetTags(ctx.Request.Context())
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 按标签获取文章列表
+func (h *Handler) getArticlesByTag(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	articles, total, err := h.service.GetArticlesByTag(ctx.Request.Context(), name, page, pageSize)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, httpserver.PageResult[ArticleWithoutContent]{
+		Page:  page,
+		Size:  pageSize,
+		Total: total,
+		Data:  articles,
+	})
+}
+
+// 热门文章，?window=24h|7d|30d|all&limit=，默认 7d、10 篇
 func (h *Handler) getHotArticles(ctx *gin.Context) {
-	data, err := h.service.GetArticlesByPopular(ctx, 10)
+	window := ctx.DefaultQuery("window", RankWeek)
+	if !slices.Contains(rankWindows, window) {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetArticlesByPopular(ctx.Request.Context(), window, limit)
 	if err != nil {
 		h.Fail(ctx, httpserver.ErrDBOp, err)
 		return
@@ -73,17 +212,507 @@ func (h *Handler) getArticleDetail(ctx *gin.Context) {
 		return
 	}
 
-	// 获取用户标识（优先使用用户ID，否则使用IP地址）
-	userID := ctx.GetString("userID") // 如果中间件设置了用户ID
-	if userID == "" {
-		userID = ctx.ClientIP() // 使用IP地址作为标识
+	format, ok := contentFormat(ctx)
+	if !ok {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
+	}
+
+	data, err := h.service.GetArticleByID(ctx.Request.Context(), id, viewer(ctx))
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data.WithFormat(format))
+}
+
+// contentFormat 解析 ?format=markdown|html，默认 markdown
+func contentFormat(ctx *gin.Context) (string, bool) {
+	format := ctx.DefaultQuery("format", FormatMarkdown)
+	return format, format == FormatMarkdown || format == FormatHTML
+}
+
+// 通过 slug 获取文章详情，曾用 slug 返回 SlugMovedResult 及新地址
+func (h *Handler) getArticleBySlug(ctx *gin.Context) {
+	format, ok := contentFormat(ctx)
+	if !ok {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
 	}
 
-	data, err := h.service.GetArticleByID(ctx.Request.Context(), id, userID)
+	data, redirect, err := h.service.GetArticleBySlug(ctx.Request.Context(), ctx.Param("slug"), viewer(ctx))
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+	if redirect != nil {
+		h.Response(ctx, httpserver.SlugMovedResult, redirect)
+		return
+	}
+
+	h.Success(ctx, data.WithFormat(format))
+}
+
+// 获取点赞和表情回应
+func (h *Handler) getReactions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetReactions(ctx.Request.Context(), id, viewer(ctx))
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 点赞 / 取消点赞
+func (h *Handler) like(like bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			h.Fail(ctx, httpserver.ErrRequest, err)
+			return
+		}
+
+		data, err := h.service.Like(ctx.Request.Context(), id, viewer(ctx), like)
+		if err != nil {
+			h.failArticle(ctx, err)
+			return
+		}
+
+		h.Success(ctx, data)
+	}
+}
+
+// 添加 / 取消表情回应
+func (h *Handler) react(on bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			h.Fail(ctx, httpserver.ErrRequest, err)
+			return
+		}
+
+		data, err := h.service.React(ctx.Request.Context(), id, ctx.Param("kind"), viewer(ctx), on)
+		if err != nil {
+			h.failArticle(ctx, err)
+			return
+		}
+
+		h.Success(ctx, data)
+	}
+}
+
+// 收藏 / 取消收藏
+func (h *Handler) bookmark(add bool) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			h.Fail(ctx, httpserver.ErrRequest, err)
+			return
+		}
+
+		if err := h.service.Bookmark(ctx.Request.Context(), id, viewer(ctx).UserID, add); err != nil {
+			h.failArticle(ctx, err)
+			return
+		}
+
+		h.Success(ctx, nil)
+	}
+}
+
+// 我的收藏
+func (h *Handler) getBookmarks(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	articles, total, err := h.service.GetBookmarks(ctx.Request.Context(), viewer(ctx).UserID, page, pageSize)
 	if err != nil {
 		h.Fail(ctx, httpserver.ErrDBOp, err)
 		return
 	}
 
+	h.Success(ctx, httpserver.PageResult[ArticleWithoutContent]{
+		Page:  page,
+		Size:  pageSize,
+		Total: total,
+		Data:  articles,
+	})
+}
+
+// viewer 从请求中获取读者身份
+func viewer(ctx *gin.Context) *Viewer {
+	v := &Viewer{}
+	if claims, ok := middleware.GetClaims(ctx); ok {
+		v.UserID = claims.UserID()
+		v.IsAdmin = claims.HasRole(middleware.RoleAdmin)
+	}
+
+	// 获取用户标识（优先使用用户ID，否则使用IP地址）
+	v.Key = ctx.GetString(middleware.UserIDKey) // 如果中间件设置了用户ID
+	if v.Key == "" {
+		v.Key = ctx.ClientIP() // 使用IP地址作为标识
+	}
+
+	// 前端路由下请求头中的 Referer 是当前页面，由前端通过 ?ref= 传入 document.referrer
+	v.Referrer = ctx.Query("ref")
+	if v.Referrer == "" {
+		v.Referrer = ctx.GetHeader("Referer")
+	}
+	// 推广参数同样由前端从页面地址中透传
+	v.UTM = analytics.UTM{
+		Source:   ctx.Query("utm_source"),
+		Medium:   ctx.Query("utm_medium"),
+		Campaign: ctx.Query("utm_campaign"),
+	}
+
+	v.Client = bot.Request{
+		UserAgent: ctx.GetHeader("User-Agent"),
+		IP:        ctx.ClientIP(),
+		Header:    ctx.Request.Header,
+	}
+	return v
+}
+
+// failArticle 将文章相关错误映射为响应
+func (h *Handler) failArticle(ctx *gin.Context, err error) {
+	switch err {
+	case ErrArticleNotFound:
+		h.FailStatus(ctx, http.StatusNotFound, httpserver.ErrArticleNotFound, nil)
+	case ErrArticleForbidden:
+		h.FailStatus(ctx, http.StatusForbidden, httpserver.ErrArticleForbidden, nil)
+	case ErrInvalidTransition:
+		h.Fail(ctx, httpserver.ErrArticleStatus, nil)
+	case ErrInvalidPublishAt:
+		h.Fail(ctx, httpserver.ErrPublishAt, nil)
+	case ErrInvalidSlug:
+		h.Fail(ctx, httpserver.ErrSlug, nil)
+	case ErrRevisionNotFound:
+		h.FailStatus(ctx, http.StatusNotFound, httpserver.ErrRevisionNotFound, nil)
+	case ErrInvalidPreview:
+		h.FailStatus(ctx, http.StatusNotFound, httpserver.ErrPreviewLink, nil)
+	case ErrInvalidReaction:
+		h.Fail(ctx, httpserver.ErrReaction, nil)
+	default:
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+	}
+}
+
+// 新建文章
+func (h *Handler) createArticle(ctx *gin.Context) {
+	var req ArticleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+	req.AuthorName = middleware.ClaimName(ctx)
+
+	data, err := h.service.CreateArticle(ctx.Request.Context(), &req, viewer(ctx).UserID)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 全量更新文章
+func (h *Handler) updateArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	var req ArticleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.UpdateArticle(ctx.Request.Context(), id, &req)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 部分更新文章
+func (h *Handler) patchArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	var req ArticlePatchRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.PatchArticle(ctx.Request.Context(), id, &req)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 删除文章
+func (h *Handler) deleteArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	if err := h.service.DeleteArticle(ctx.Request.Context(), id); err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, nil)
+}
+
+// 生成草稿预览分享链接，ttl 为有效期（如 24h），缺省使用配置
+func (h *Handler) shareArticle(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	var ttl time.Duration
+	if v := ctx.Query("ttl"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			h.Fail(ctx, httpserver.ErrRequest, err)
+			return
+		}
+	}
+
+	data, err := h.service.CreatePreviewLink(ctx.Request.Context(), id, ttl)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 通过分享链接预览文章
+func (h *Handler) getArticlePreview(ctx *gin.Context) {
+	format, ok := contentFormat(ctx)
+	if !ok {
+		h.Fail(ctx, httpserver.ErrRequest, nil)
+		return
+	}
+
+	data, err := h.service.GetArticlePreview(ctx.Request.Context(), ctx.Param("token"))
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data.WithFormat(format))
+}
+
+// 获取修订列表
+func (h *Handler) getRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetRevisions(ctx.Request.Context(), id)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 获取单个修订
+func (h *Handler) getRevision(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	rid, err := strconv.Atoi(ctx.Param("rid"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.GetRevision(ctx.Request.Context(), id, rid)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+// 比较两个修订，?from=&to=
+func (h *Handler) diffRevisions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.DiffRevisions(ctx.Request.Context(), id, from, to)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
 	h.Success(ctx, data)
 }
+
+// 恢复修订
+func (h *Handler) restoreRevision(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	rid, err := strconv.Atoi(ctx.Param("rid"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.RestoreRevision(ctx.Request.Context(), id, rid)
+	if err != nil {
+		h.failArticle(ctx, err)
+		return
+	}
+
+	h.Success(ctx, data)
+}
+
+var feedContentTypes = map[string]string{
+	FeedRSS:  "application/rss+xml; charset=utf-8",
+	FeedAtom: "application/atom+xml; charset=utf-8",
+	FeedJSON: "application/feed+json; charset=utf-8",
+}
+
+// 订阅源，支持 If-None-Match / If-Modified-Since 条件请求
+func (h *Handler) getFeed(format string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		data, err := h.service.GetFeed(ctx.Request.Context(), format, ctx.Param("name"))
+		if err == ErrFeedNotFound {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			ctx.Status(http.StatusInternalServerError)
+			return
+		}
+
+		ctx.Header("ETag", data.ETag)
+		if !data.LastModified.IsZero() {
+			ctx.Header("Last-Modified", data.LastModified.UTC().Format(http.TimeFormat))
+		}
+
+		if notModified(ctx, data) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+
+		ctx.Data(http.StatusOK, feedContentTypes[format], data.Body)
+	}
+}
+
+// notModified 判断条件请求是否命中，If-None-Match 优先
+func notModified(ctx *gin.Context, data *FeedBody) bool {
+	if inm := ctx.GetHeader("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == data.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := ctx.GetHeader("If-Modified-Since"); ims != "" && !data.LastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		if err == nil && !data.LastModified.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// sitemap，/sitemaps/:file 为 sitemap index 下的分片，如 /sitemaps/1.xml
+func (h *Handler) getSitemap(ctx *gin.Context) {
+	part := 0
+	if file := ctx.Param("file"); file != "" {
+		n, err := strconv.Atoi(strings.TrimSuffix(file, ".xml"))
+		if err != nil || n <= 0 || !strings.HasSuffix(file, ".xml") {
+			ctx.Status(http.StatusNotFound)
+			return
+		}
+		part = n
+	}
+
+	data, err := h.service.GetSitemap(ctx.Request.Context(), part)
+	if err == ErrSitemapNotFound && part == 0 {
+		// 首次生成尚未完成
+		ctx.Header("Retry-After", "60")
+		ctx.Status(http.StatusServiceUnavailable)
+		return
+	}
+	if err == ErrSitemapNotFound {
+		ctx.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ctx.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx.Data(http.StatusOK, "application/xml; charset=utf-8", data)
+}
+
+func (h *Handler) getRobots(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", h.service.GetRobots(ctx.Request.Context()))
+}
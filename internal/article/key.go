@@ -1,32 +1,165 @@
 package article
 
 import (
+	"crypto/sha1"
 	"fmt"
 )
 
-func ArticleTotalKey() string {
-	return "Article:Total"
-}
-
 func ArticleByIDKey(id int) string {
 	return fmt.Sprintf("Article:ByID:%d", id)
 }
 
+// ArticleBySlugKey slug -> 文章ID
+func ArticleBySlugKey(slug string) string {
+	return fmt.Sprintf("Article:BySlug:%s", slug)
+}
+
 func ArticleByPageKey(page, pageSize int) string {
 	return fmt.Sprintf("Article:ByPage:%d:%d", page, pageSize)
 }
 
-func ArticleByPopularKey(limit int) string {
-	return fmt.Sprintf("Article:ByPopular:%d", limit)
+func ArticleByPopularKey(window string, limit int) string {
+	return fmt.Sprintf("Article:ByPopular:%s:%d", window, limit)
+}
+
+// ArticleRankKey 热门排行的有序集合，每个时间窗口一个
+func ArticleRankKey(window string) string {
+	return fmt.Sprintf("Article:Rank:%s", window)
+}
+
+// ArticleRankRebuildKey 从数据库重建中的临时排行，完成后 RENAME 为 ArticleRankKey
+func ArticleRankRebuildKey(window string) string {
+	return fmt.Sprintf("Article:Rank:Rebuild:%s", window)
+}
+
+// ArticleRankBuiltKey 排行已从数据库重建的标记，Redis 数据丢失后随之消失
+func ArticleRankBuiltKey() string {
+	return "Article:Rank:Built"
+}
+
+// ArticleRankLockKey 重建排行的锁，避免多个实例同时重建
+func ArticleRankLockKey() string {
+	return "Article:Rank:Lock"
+}
+
+// ArticleRankLikedKey 已为点赞加过分的读者标识集合，取消后再点赞不重复加分
+func ArticleRankLikedKey(id int) string {
+	return fmt.Sprintf("Article:Rank:Liked:%d", id)
+}
+
+// ArticleRankEpochKey 各排行有序集合的计分纪元，排行 key -> unix 秒
+func ArticleRankEpochKey() string {
+	return "Article:Rank:Epoch"
+}
+
+func ArticleTagsKey() string {
+	return "Article:Tags"
+}
+
+func ArticleByTagKey(name string, page, pageSize int) string {
+	return fmt.Sprintf("Article:ByTag:%s:%d:%d", name, page, pageSize)
+}
+
+// ArticleSearchKey 检索结果缓存，检索词取 sha1 避免 key 过长
+func ArticleSearchKey(q string, page, pageSize int) string {
+	return fmt.Sprintf("Article:Search:%x:%d:%d", sha1.Sum([]byte(q)), page, pageSize)
+}
+
+// ArticleFeedKey 订阅源缓存，tag 为空表示全站
+func ArticleFeedKey(format, tag string) string {
+	if tag == "" {
+		return fmt.Sprintf("Article:Feed:%s", format)
+	}
+	return fmt.Sprintf("Article:Feed:%s:Tag:%s", format, tag)
+}
+
+// ArticleGroup 包含该文章的列表缓存分组
+func ArticleGroup(id int) string {
+	return fmt.Sprintf("Article:%d", id)
+}
+
+// ArticleListGroup 依赖公开文章集合的列表缓存分组，文章进入或离开公开状态时失效
+func ArticleListGroup() string {
+	return "Article:List"
 }
 
+// ArticleTagGroup 依赖某个标签下文章集合的列表缓存分组
+func ArticleTagGroup(name string) string {
+	return fmt.Sprintf("Article:Tag:%s", name)
+}
+
+// ArticleTagsGroup 标签列表缓存的分组，标签或公开文章变化时失效
+func ArticleTagsGroup() string {
+	return "Article:Tags"
+}
+
+// ArticleSitemapKey /sitemap.xml 的内容，文章数超过单文件上限时为 sitemap index
+func ArticleSitemapKey() string {
+	return "Article:Sitemap"
+}
+
+// ArticleSitemapPartsKey 上次生成的分片数，重新生成时据此删除多余的分片
+func ArticleSitemapPartsKey() string {
+	return "Article:Sitemap:Parts"
+}
+
+// ArticleSitemapPartKey sitemap index 下的第 part 个分片，从 1 开始
+func ArticleSitemapPartKey(part int) string {
+	return fmt.Sprintf("Article:Sitemap:%d", part)
+}
+
+func ArticleRobotsKey() string {
+	return "Article:Robots"
+}
+
+// ArticleLikeKey 点赞过文章的读者标识集合
+func ArticleLikeKey(id int) string {
+	return fmt.Sprintf("Article:Like:%d", id)
+}
+
+// ArticleReactionKey 对文章回应过某表情的读者标识集合
+func ArticleReactionKey(id int, kind string) string {
+	return fmt.Sprintf("Article:Reaction:%d:%s", id, kind)
+}
+
+// ArticleReactionLoadedKey 标记文章的点赞和回应集合已与数据库对齐，不存在时需重新加载
+func ArticleReactionLoadedKey(id int) string {
+	return fmt.Sprintf("Article:Reaction:Loaded:%d", id)
+}
+
+// ArticleReactionDirtyKey 点赞或回应有变化、待同步到数据库的文章ID集合
+func ArticleReactionDirtyKey() string {
+	return "Article:Reaction:Dirty"
+}
+
+// ArticleReactionFlushingKey 正在同步的文章ID集合
+func ArticleReactionFlushingKey() string {
+	return "Article:Reaction:Flushing"
+}
+
+// ArticleActiveViewIDsKey 有新浏览、待同步浏览量的文章ID集合
 func ArticleActiveViewIDsKey() string {
 	return "Article:View:ActiveIDs"
 }
 
 func ArticleViewKey(id int) string {
 	if id == -1 {
-		return "Article:view:UV:*"
+		return "Article:View:UV:*"
 	}
 	return fmt.Sprintf("Article:View:UV:%d", id)
 }
+
+// ArticleViewMigratedKey 遗留的 UV 已补入待同步集合的标记
+func ArticleViewMigratedKey() string {
+	return "Article:View:Migrated"
+}
+
+// ArticleViewBatchKey 待写入数据库的浏览量批次，hash 文章ID -> 增量
+func ArticleViewBatchKey(batch string) string {
+	return fmt.Sprintf("Article:View:Batch:%s", batch)
+}
+
+// ArticleViewBatchesKey 待写入数据库的批次ID集合
+func ArticleViewBatchesKey() string {
+	return "Article:View:Batches"
+}
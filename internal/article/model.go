@@ -1,6 +1,13 @@
 package article
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"my_web/backend/internal/analytics"
+	"my_web/backend/internal/bot"
+	"my_web/backend/internal/markdown"
+	"strings"
 	"time"
 )
 
@@ -9,27 +16,336 @@ type ArticleStatus uint8
 const (
 	ArticlePublic = iota
 	ArticlePrivate
+	ArticleDraft     // 草稿
+	ArticleScheduled // 定时发布，到达 PublishAt 后转为公开
+	ArticleArchived  // 归档
 )
 
+// articleTransitions 允许的状态转换，同状态之间总是允许
+var articleTransitions = map[ArticleStatus][]ArticleStatus{
+	ArticleDraft:     {ArticleScheduled, ArticlePublic, ArticlePrivate, ArticleArchived},
+	ArticleScheduled: {ArticleDraft, ArticlePublic, ArticlePrivate},
+	ArticlePublic:    {ArticlePrivate, ArticleArchived},
+	ArticlePrivate:   {ArticlePublic, ArticleDraft, ArticleArchived},
+	ArticleArchived:  {ArticlePublic, ArticlePrivate, ArticleDraft},
+}
+
+// CanTransitionTo 判断能否从当前状态转换到目标状态
+func (s ArticleStatus) CanTransitionTo(to ArticleStatus) bool {
+	if s == to {
+		return true
+	}
+	for _, next := range articleTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
 type Article struct {
-	ID         int           `gorm:"primaryKey;autoIncrement" json:"id"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
-	Title      string        `json:"title"`               // 标题
-	Desc       string        `json:"desc" gorm:"text"`    // 描述
-	Content    string        `json:"content" gorm:"text"` // 正文
-	AuthorName string        `json:"authorName"`          // 作者
-	Views      uint          `json:"views"`               // 浏览数
-	Tags       string        `json:"tags"`                // 标签（逗号分隔形式）
-	Cover      string        `json:"cover"`               // 封面
-	Status     ArticleStatus `json:"status"`              // 状态
-	IsDelete   bool          `json:"is_delete"`
+	ID            int            `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Title         string         `json:"title"`                  // 标题
+	Desc          string         `json:"desc" gorm:"text"`       // 描述
+	Content       string         `json:"content" gorm:"text"`    // 正文
+	AuthorName    string         `json:"authorName"`             // 作者
+	AuthorID      int            `json:"authorId" gorm:"index"`  // 作者用户ID，0 表示未知
+	Views         uint           `json:"views"`                  // 浏览数
+	Tags          string         `json:"tags"`                   // 标签（逗号分隔形式）
+	Cover         string         `json:"cover"`                  // 封面
+	Status        ArticleStatus  `json:"status"`                 // 状态
+	PublishAt     *time.Time     `json:"publishAt" gorm:"index"` // 发布时间，定时发布时为计划时间
+	IsDelete      bool           `json:"is_delete"`
+	Slug          string         `json:"slug" gorm:"size:128;index:idx_articles_slug,unique,where:slug <> ''"` // URL 别名
+	ContentHTML   string         `json:"contentHtml,omitempty" gorm:"type:text"`                               // 由 Content 渲染并过滤后的 HTML
+	TOC           TOC            `json:"toc" gorm:"type:text"`                                                 // 目录
+	WordCount     int            `json:"wordCount"`                                                            // 字数
+	CharCount     int            `json:"charCount"`                                                            // 字符数
+	ReadingTime   int            `json:"readingTime"`                                                          // 预计阅读分钟数
+	RenderVersion int            `json:"-" gorm:"not null;default:0"`                                          // 生成 ContentHTML 及统计时的 markdown.Version
+	CommentCount  int            `json:"commentCount" gorm:"not null;default:0"`                               // 已审核通过的评论数
+	Likes         int            `json:"likes" gorm:"not null;default:0"`                                      // 点赞数，由定时任务从 Redis 同步
+	Reactions     ReactionCounts `json:"reactions" gorm:"type:text"`                                           // 各表情回应数，由定时任务从 Redis 同步
+	TagList       []Tag          `json:"-" gorm:"many2many:article_tags;"`                                     // 规范化标签，由 Tags 同步
+}
+
+// TOC 文章目录，以 JSON 存储
+type TOC []markdown.Heading
+
+func (t TOC) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+func (t *TOC) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("无法解析目录类型 %T", src)
+	}
+	if len(data) == 0 {
+		*t = nil
+		return nil
+	}
+	return json.Unmarshal(data, t)
+}
+
+// applyDocument 写入渲染结果及统计
+func (a *Article) applyDocument(doc *markdown.Document) {
+	a.ContentHTML = doc.HTML
+	a.TOC = doc.TOC
+	a.WordCount = doc.WordCount
+	a.CharCount = doc.CharCount
+	a.ReadingTime = doc.ReadingTime
+	a.RenderVersion = markdown.Version
+}
+
+// documentUpdates 渲染结果对应的更新字段
+func documentUpdates(doc *markdown.Document) map[string]any {
+	return map[string]any{
+		"content_html":   doc.HTML,
+		"toc":            TOC(doc.TOC),
+		"word_count":     doc.WordCount,
+		"char_count":     doc.CharCount,
+		"reading_time":   doc.ReadingTime,
+		"render_version": markdown.Version,
+	}
+}
+
+// 热门排行的窗口，每个窗口对应一个半衰期：旧数据按半衰期衰减而不是在窗口外被丢弃，
+// 名称表示该排行大致反映的时间范围
+const (
+	RankDay   = "24h"
+	RankWeek  = "7d"
+	RankMonth = "30d"
+	RankAll   = "all"
+)
+
+var rankWindows = []string{RankDay, RankWeek, RankMonth, RankAll}
+
+// ReactionCounts 表情 -> 回应数，以 JSON 存储
+type ReactionCounts map[string]int
+
+func (r ReactionCounts) Value() (driver.Value, error) {
+	if r == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(r)
+	return string(data), err
+}
+
+func (r *ReactionCounts) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("无法解析表情回应类型 %T", src)
+	}
+	if len(data) == 0 {
+		*r = nil
+		return nil
+	}
+	return json.Unmarshal(data, r)
+}
+
+// ReactionSummary 文章的点赞和表情回应，Liked 和 Mine 为当前读者的状态
+type ReactionSummary struct {
+	Likes     int            `json:"likes"`
+	Reactions ReactionCounts `json:"reactions"`
+	Liked     bool           `json:"liked"`
+	Mine      []string       `json:"mine"`
+}
+
+// Bookmark 用户收藏的文章
+type Bookmark struct {
+	UserID    int       `gorm:"primaryKey;autoIncrement:false" json:"userId"`
+	ArticleID int       `gorm:"primaryKey;autoIncrement:false;index" json:"articleId"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// reactionLike 点赞在 Reaction 中的 Kind
+const reactionLike = "like"
+
+// Reaction 读者的点赞或表情回应，Redis 中的集合丢失后据此恢复
+type Reaction struct {
+	ArticleID int    `gorm:"primaryKey;autoIncrement:false"`
+	Kind      string `gorm:"primaryKey;size:32"` // 表情，点赞为 like
+	Member    string `gorm:"primaryKey;size:64"` // 读者标识，用户ID或IP
+	CreatedAt time.Time
+}
+
+// ViewBatch 已写入数据库的浏览量批次，批次重试时据此避免重复累加
+type ViewBatch struct {
+	BatchID   string    `gorm:"primaryKey;size:64"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// SlugHistory 文章曾用的 slug，用于旧地址跳转
+type SlugHistory struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Slug      string    `gorm:"size:128;uniqueIndex" json:"slug"`
+	ArticleID int       `gorm:"index" json:"articleId"`
+}
+
+// SlugRedirect 旧 slug 对应的新地址
+type SlugRedirect struct {
+	ID   int    `json:"id"`
+	Slug string `json:"slug"`
+
+	// 目标文章的状态和作者，用于判断读者能否看到跳转目标，不返回给前端
+	Status   ArticleStatus `json:"-"`
+	AuthorID int           `json:"-"`
+}
+
+// Tag 标签，与文章多对多关联
+type Tag struct {
+	ID   int    `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name string `gorm:"size:64;uniqueIndex" json:"name"`
+}
+
+// TagWithCount 标签及其公开文章数
+type TagWithCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// splitTags 解析逗号分隔的标签字符串，去空去重
+func splitTags(tags string) []string {
+	fields := strings.FieldsFunc(tags, func(r rune) bool {
+		return r == ',' || r == '，'
+	})
+
+	names := make([]string, 0, len(fields))
+	seen := map[string]struct{}{}
+	for _, f := range fields {
+		name := strings.TrimSpace(f)
+		if name == "" {
+			continue
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+)
+
+// WithFormat 只保留指定格式的正文，返回副本
+func (a *Article) WithFormat(format string) *Article {
+	out := *a
+	if format == FormatHTML {
+		out.Content = ""
+	} else {
+		out.ContentHTML = ""
+	}
+	return &out
+}
+
+const (
+	FeedRSS  = "rss"
+	FeedAtom = "atom"
+	FeedJSON = "json"
+)
+
+// FeedBody 生成好的订阅源
+type FeedBody struct {
+	Body         []byte    `json:"body"`
+	ETag         string    `json:"etag"`
+	LastModified time.Time `json:"lastModified"` // 最新文章的 UpdatedAt，无文章时为零值
+	ArticleIDs   []int     `json:"articleIds"`   // 包含的文章，用于缓存分组
+}
+
+// Viewer 访问文章的读者
+type Viewer struct {
+	UserID   int    // 登录用户ID，匿名为 0
+	IsAdmin  bool   // 是否管理员
+	Key      string // 防重复计数的标识，用户ID或IP
+	Referrer string // 来源页面地址
+	UTM      analytics.UTM
+
+	Client bot.Request // 用于识别爬虫的 UA、IP 和请求头
+}
+
+// canView 判断读者能否查看文章
+func (a *Article) canView(v *Viewer) bool {
+	if a.Status == ArticlePublic {
+		return true
+	}
+	return v.IsAdmin || (v.UserID != 0 && v.UserID == a.AuthorID)
+}
+
+// ArticleRevision 文章修订记录，每次创建和更新时保存一份快照
+type ArticleRevision struct {
+	ID        int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	ArticleID int       `gorm:"index" json:"articleId"`
+	CreatedAt time.Time `json:"created_at"`
+	Title     string    `json:"title"`
+	Desc      string    `json:"desc" gorm:"text"`
+	Content   string    `json:"content,omitempty" gorm:"text"`
+	Tags      string    `json:"tags"`
+}
+
+func newRevision(a *Article) *ArticleRevision {
+	return &ArticleRevision{
+		ArticleID: a.ID,
+		Title:     a.Title,
+		Desc:      a.Desc,
+		Content:   a.Content,
+		Tags:      a.Tags,
+	}
+}
+
+// text 将快照拼成用于 diff 的文本
+func (r *ArticleRevision) text() string {
+	return "title: " + r.Title + "\n" +
+		"desc: " + r.Desc + "\n" +
+		"tags: " + r.Tags + "\n" +
+		"\n" + r.Content
+}
+
+// RevisionDiff 两个修订之间的差异
+type RevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"` // unified diff，相同时为空
+}
+
+// PreviewLink 草稿预览分享链接
+type PreviewLink struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // ---------------------------------------
 
 type ArticleWithoutContent struct {
 	ID         int       `json:"id"`
+	Slug       string    `json:"slug"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 	Title      string    `json:"title"`
@@ -37,4 +353,74 @@ type ArticleWithoutContent struct {
 	Views      uint      `json:"views"`      // 浏览数
 	Tags       string    `json:"tags"`       // 标签（逗号分隔）
 	Cover      string    `json:"cover"`      // 封面
+
+	WordCount   int `json:"wordCount"`   // 字数
+	CharCount   int `json:"charCount"`   // 字符数
+	ReadingTime int `json:"readingTime"` // 预计阅读分钟数
+
+	CommentCount int            `json:"commentCount"` // 已审核通过的评论数
+	Likes        int            `json:"likes"`        // 点赞数
+	Reactions    ReactionCounts `json:"reactions"`    // 各表情回应数
+}
+
+// SearchArticle 搜索结果，Highlight 为 ts_headline 生成的摘要片段，已转义，只含 <mark> 标签
+type SearchArticle struct {
+	ArticleWithoutContent
+	Highlight string `json:"highlight"`
+}
+
+// ---------------------------------------
+
+// ArticleRequest 创建/全量更新文章的请求体
+type ArticleRequest struct {
+	Title      string         `json:"title" binding:"required,max=255"`
+	Slug       string         `json:"slug" binding:"max=80"` // 为空时由标题生成
+	Desc       string         `json:"desc" binding:"max=1000"`
+	Content    string         `json:"content" binding:"required"`
+	AuthorName string         `json:"-"` // 新建时由 handler 取自 token，不接受客户端指定；更新时不修改作者
+	Tags       string         `json:"tags" binding:"max=255"`
+	Cover      string         `json:"cover" binding:"max=512"`
+	Status     *ArticleStatus `json:"status" binding:"omitempty,oneof=0 1 2 3 4"` // 新建时省略为草稿，全量更新时省略为不修改
+	PublishAt  *time.Time     `json:"publishAt"`                                  // 定时发布时必填
+}
+
+// ArticlePatchRequest 部分更新文章的请求体，nil 字段不修改
+type ArticlePatchRequest struct {
+	Title     *string        `json:"title" binding:"omitempty,min=1,max=255"`
+	Slug      *string        `json:"slug" binding:"omitempty,max=80"`
+	Desc      *string        `json:"desc" binding:"omitempty,max=1000"`
+	Content   *string        `json:"content" binding:"omitempty,min=1"`
+	Tags      *string        `json:"tags" binding:"omitempty,max=255"`
+	Cover     *string        `json:"cover" binding:"omitempty,max=512"`
+	Status    *ArticleStatus `json:"status" binding:"omitempty,oneof=0 1 2 3 4"`
+	PublishAt *time.Time     `json:"publishAt"`
+}
+
+func (r *ArticlePatchRequest) updates() map[string]any {
+	m := map[string]any{}
+	if r.Title != nil {
+		m["title"] = *r.Title
+	}
+	if r.Slug != nil {
+		m["slug"] = *r.Slug
+	}
+	if r.Desc != nil {
+		m["desc"] = *r.Desc
+	}
+	if r.Content != nil {
+		m["content"] = *r.Content
+	}
+	if r.Tags != nil {
+		m["tags"] = *r.Tags
+	}
+	if r.Cover != nil {
+		m["cover"] = *r.Cover
+	}
+	if r.Status != nil {
+		m["status"] = *r.Status
+	}
+	if r.PublishAt != nil {
+		m["publish_at"] = r.PublishAt
+	}
+	return m
 }
@@ -0,0 +1,61 @@
+package article
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreviewToken(t *testing.T) {
+	const secret = "0123456789abcdef0123456789abcdef"
+	now := time.Unix(1_700_000_000, 0)
+	valid := signPreview(secret, 42, now.Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		now    time.Time
+		wantID int
+		ok     bool
+	}{
+		{"有效", secret, valid, now, 42, true},
+		{"过期", secret, valid, now.Add(2 * time.Hour), 0, false},
+		{"密钥不同", "another-secret-another-secret-xx", valid, now, 0, false},
+		{"空密钥", "", signPreview("", 42, now.Add(time.Hour)), now, 0, false},
+		{"篡改ID", secret, "43" + strings.TrimPrefix(valid, "42"), now, 0, false},
+		{"篡改过期时间", secret, strings.Replace(valid, ".", ".9", 1), now, 0, false},
+		{"格式错误", secret, "42.abc", now, 0, false},
+		{"非数字ID", secret, "x.1.sig", now, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := verifyPreview(tt.secret, tt.token, tt.now)
+			if tt.ok != (err == nil) {
+				t.Fatalf("err = %v, want ok = %v", err, tt.ok)
+			}
+			if id != tt.wantID {
+				t.Fatalf("id = %d, want %d", id, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestPreviewable(t *testing.T) {
+	tests := []struct {
+		status ArticleStatus
+		want   bool
+	}{
+		{ArticlePublic, false},
+		{ArticlePrivate, false},
+		{ArticleDraft, true},
+		{ArticleScheduled, true},
+		{ArticleArchived, false},
+	}
+	for _, tt := range tests {
+		if got := previewable(tt.status); got != tt.want {
+			t.Errorf("previewable(%d) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package article
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+func rankScore(t *testing.T, rdb *redis.Client, window string, id int) float64 {
+	t.Helper()
+	score, err := rdb.ZScore(context.Background(), ArticleRankKey(window), strconv.Itoa(id)).Result()
+	if err == redis.Nil {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return score
+}
+
+func TestRankDecay(t *testing.T) {
+	const hl = time.Hour
+	windows := []string{RankDay, RankAll}
+	halfLives := []time.Duration{hl, 0}
+	now := time.Now().Truncate(time.Second)
+
+	type event struct {
+		weight float64
+		at     time.Duration // 相对 now
+	}
+	tests := []struct {
+		name     string
+		events   []event
+		wantDay  float64 // 以第一个事件写入的纪元（当前时间）为基准
+		wantAll  float64
+		tolerant float64
+	}{
+		{"当前时间加分", []event{{10, 0}}, 10, 10, 1e-6},
+		{"一个半衰期之前加分减半", []event{{10, -hl}}, 5, 10, 1e-6},
+		{"两个半衰期之前", []event{{8, -2 * hl}}, 2, 8, 1e-6},
+		{"加分后按原时间扣分相互抵消", []event{{10, -3 * hl}, {-10, -3 * hl}}, 0, 0, 1e-6},
+		{"评论通过后撤销再通过", []event{{10, -hl}, {-10, -hl}, {10, -hl}}, 5, 10, 1e-6},
+		{"按当前时间扣分不会变为负数", []event{{10, -2 * hl}, {-10, 0}}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, rdb := newTestRedis(t)
+			ctx := context.Background()
+			for _, e := range tt.events {
+				if err := cacheRankIncr(ctx, rdb, 1, e.weight, now.Add(e.at), windows, halfLives); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := rankScore(t, rdb, RankDay, 1); math.Abs(got-tt.wantDay) > tt.tolerant+1e-3 {
+				t.Errorf("day = %v, want %v", got, tt.wantDay)
+			}
+			if got := rankScore(t, rdb, RankAll, 1); math.Abs(got-tt.wantAll) > tt.tolerant+1e-3 {
+				t.Errorf("all = %v, want %v", got, tt.wantAll)
+			}
+		})
+	}
+}
+
+func TestRankFirstLike(t *testing.T) {
+	_, rdb := newTestRedis(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		member string
+		want   bool
+	}{
+		{"u1", true},
+		{"u1", false}, // 取消后再次点赞
+		{"u2", true},
+	}
+	for _, tt := range tests {
+		got, err := cacheRankFirstLike(ctx, rdb, 1, tt.member)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("cacheRankFirstLike(%s) = %v, want %v", tt.member, got, tt.want)
+		}
+	}
+}
+
+func TestRankRebuildSwap(t *testing.T) {
+	mr, rdb := newTestRedis(t)
+	ctx := context.Background()
+	windows := []string{RankDay, RankAll}
+	halfLives := []time.Duration{time.Hour, 0}
+
+	// Redis 数据丢失后第一次点赞重新创建了排行，但没有重建标记
+	if err := cacheRankIncr(ctx, rdb, 1, 5, time.Now(), windows, halfLives); err != nil {
+		t.Fatal(err)
+	}
+	if built, _ := cacheRankBuilt(ctx, rdb); built {
+		t.Fatal("不应有重建标记")
+	}
+
+	locked, err := cacheRankLock(ctx, rdb, time.Minute)
+	if err != nil || !locked {
+		t.Fatalf("第一次抢锁 = %v, %v", locked, err)
+	}
+	if again, _ := cacheRankLock(ctx, rdb, time.Minute); again {
+		t.Fatal("其他实例不应拿到锁")
+	}
+
+	if err := cacheRankRebuildReset(ctx, rdb, windows); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacheRankRebuildIncr(ctx, rdb, 1, 100, time.Now(), windows, halfLives); err != nil {
+		t.Fatal(err)
+	}
+	if err := cacheRankSwap(ctx, rdb, windows); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := rankScore(t, rdb, RankAll, 1); got != 100 {
+		t.Errorf("重建后分数 = %v, want 100（不重复累加）", got)
+	}
+	if mr.Exists(ArticleRankRebuildKey(RankAll)) {
+		t.Error("临时排行应已被替换")
+	}
+	if built, _ := cacheRankBuilt(ctx, rdb); !built {
+		t.Error("应写入重建标记")
+	}
+	if mr.HGet(ArticleRankEpochKey(), ArticleRankKey(RankDay)) == "" {
+		t.Error("纪元应迁移到正式排行")
+	}
+}
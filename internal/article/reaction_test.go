@@ -0,0 +1,81 @@
+package article
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheSetMemberMarksDirtyOnlyOnChange(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+
+	tests := []struct {
+		name    string
+		add     bool
+		changed bool
+		dirty   bool
+	}{
+		{"点赞", true, true, true},
+		{"重复点赞", true, false, false},
+		{"取消点赞", false, true, true},
+		{"重复取消", false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdb.Del(ctx, ArticleReactionDirtyKey())
+			changed, err := cacheSetMember(ctx, rdb, 1, ArticleLikeKey(1), "u:1", tt.add)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if dirty := rdb.SIsMember(ctx, ArticleReactionDirtyKey(), 1).Val(); dirty != tt.dirty {
+				t.Errorf("dirty = %v, want %v", dirty, tt.dirty)
+			}
+		})
+	}
+}
+
+func TestCacheLoadReactions(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	kinds := []string{"heart", "rocket"}
+
+	if loaded, _ := cacheReactionsLoaded(ctx, rdb, 1); loaded {
+		t.Fatal("loaded before load")
+	}
+
+	// Redis 中残留的旧记录与数据库中的记录合并
+	rdb.SAdd(ctx, ArticleLikeKey(1), "ip:1.2.3.4")
+	stored := []Reaction{
+		{ArticleID: 1, Kind: reactionLike, Member: "u:1"},
+		{ArticleID: 1, Kind: reactionLike, Member: "ip:1.2.3.4"},
+		{ArticleID: 1, Kind: "heart", Member: "u:2"},
+	}
+	if err := cacheLoadReactions(ctx, rdb, 1, stored); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded, _ := cacheReactionsLoaded(ctx, rdb, 1); !loaded {
+		t.Fatal("not loaded after load")
+	}
+	members, err := cacheGetReactionMembers(ctx, rdb, 1, kinds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 3 {
+		t.Fatalf("members = %v, want 3", members)
+	}
+
+	summary, err := cacheGetReactions(ctx, rdb, 1, kinds, "u:2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Likes != 2 || summary.Reactions["heart"] != 1 || summary.Liked {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(summary.Mine) != 1 || summary.Mine[0] != "heart" {
+		t.Errorf("mine = %v, want [heart]", summary.Mine)
+	}
+}
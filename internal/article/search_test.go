@@ -0,0 +1,24 @@
+package article
+
+import "testing"
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{"纯文本", "hello world", "hello world"},
+		{"高亮", "say \x02hello\x03 world", "say <mark>hello</mark> world"},
+		{"正文中的标签被转义", "<script>alert(1)</script> \x02go\x03", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>go</mark>"},
+		{"属性被转义", `<img src=x onerror="alert(1)">`, `&lt;img src=x onerror=&#34;alert(1)&#34;&gt;`},
+		{"原文中的 mark 不生效", "<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlightHTML(tt.headline); got != tt.want {
+				t.Errorf("highlightHTML(%q) = %q, want %q", tt.headline, got, tt.want)
+			}
+		})
+	}
+}
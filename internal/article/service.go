@@ -2,120 +2,1474 @@ package article
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"my_web/backend/internal/analytics"
+	"my_web/backend/internal/cache"
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/event"
+	"my_web/backend/internal/feed"
+	"my_web/backend/internal/markdown"
+	"my_web/backend/internal/sitemap"
 	"my_web/backend/internal/utils"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrArticleNotFound   = errors.New("article not found")
+	ErrArticleForbidden  = errors.New("article forbidden")
+	ErrInvalidTransition = errors.New("invalid article status transition")
+	ErrInvalidPublishAt  = errors.New("invalid publish time")
+	ErrInvalidPreview    = errors.New("invalid preview token")
+	ErrRevisionNotFound  = errors.New("revision not found")
+	ErrInvalidSlug       = errors.New("invalid slug")
+	ErrInvalidFeed       = errors.New("invalid feed format")
+	ErrFeedNotFound      = errors.New("feed tag not found")
+	ErrSitemapNotFound   = errors.New("sitemap not found")
+	ErrInvalidReaction   = errors.New("invalid reaction")
+)
+
+var defaultReactions = []string{"heart", "laugh", "hooray", "confused", "rocket", "eyes"}
+
 type Service struct {
 	DB  *gorm.DB
 	RDB *redis.Client
 
-	task utils.TaskRunner
+	conf  *config.ArticleConfig
+	site  *config.SiteConfig
+	stats *analytics.Service
+	bus   *event.Bus
+
+	pop popularity
+
+	caches caches
+
+	task        utils.TaskRunner
+	publishTask utils.TaskRunner
+	sitemapTask utils.TaskRunner
+	rankTask    utils.TaskRunner
+}
+
+// popularity 热门排行评分参数，未配置的项使用默认值
+type popularity struct {
+	view      float64
+	like      float64
+	comment   float64
+	publish   float64
+	halfLives []time.Duration // 与 rankWindows 一一对应，0 表示不衰减
+}
+
+func newPopularity(conf *config.PopularityConfig) popularity {
+	or := func(v, def float64) float64 {
+		if v > 0 {
+			return v
+		}
+		return def
+	}
+	orDuration := func(v, def time.Duration) time.Duration {
+		if v > 0 {
+			return v
+		}
+		return def
+	}
+
+	return popularity{
+		view:    or(conf.ViewWeight, 1),
+		like:    or(conf.LikeWeight, 5),
+		comment: or(conf.CommentWeight, 10),
+		publish: or(conf.PublishWeight, 20),
+		halfLives: []time.Duration{
+			orDuration(conf.HalfLifeDay, 6*time.Hour),
+			orDuration(conf.HalfLifeWeek, 48*time.Hour),
+			orDuration(conf.HalfLifeMonth, 7*24*time.Hour),
+			0,
+		},
+	}
 }
 
-func NewArticleService(ctx context.Context, db *gorm.DB, rdb *redis.Client) *Service {
+func NewArticleService(ctx context.Context, db *gorm.DB, rdb *redis.Client, conf *config.ArticleConfig, site *config.SiteConfig, cacheConf *config.CacheConfig, stats *analytics.Service, bus *event.Bus, metrics cache.Metrics) *Service {
 	service := &Service{
-		DB:  db,
-		RDB: rdb,
+		DB:     db,
+		RDB:    rdb,
+		conf:   conf,
+		site:   site,
+		stats:  stats,
+		bus:    bus,
+		pop:    newPopularity(&conf.Popularity),
+		caches: newCaches(rdb, cacheConf, metrics),
 	}
 
+	articleEvents := []event.Type{event.ArticleCreated, event.ArticleUpdated, event.ArticleDeleted, event.ArticlePublished}
+	bus.Subscribe(service.onArticleEvent, articleEvents...)
+	bus.SubscribeAll(service.onArticleEventLocal, articleEvents...)
+
 	service.task = *utils.NewTaskRunner(
 		service,
 		utils.WithInterval(1*time.Hour),
 		utils.WithTimeout(1*time.Minute),
 	)
 
+	publishInterval := conf.PublishInterval
+	if publishInterval <= 0 {
+		publishInterval = time.Minute
+	}
+	service.publishTask = *utils.NewTaskRunner(
+		&scheduledPublisher{service},
+		utils.WithInterval(publishInterval),
+		utils.WithTimeout(publishInterval),
+	)
+
+	sitemapInterval := site.SitemapInterval
+	if sitemapInterval <= 0 {
+		sitemapInterval = time.Hour
+	}
+	service.sitemapTask = *utils.NewTaskRunner(
+		&sitemapGenerator{service},
+		utils.WithInterval(sitemapInterval),
+		utils.WithTimeout(5*time.Minute),
+	)
+
+	service.rankTask = *utils.NewTaskRunner(
+		&rankMaintainer{service},
+		utils.WithInterval(time.Hour),
+		utils.WithTimeout(5*time.Minute),
+	)
+
 	service.task.Start(ctx)
+	service.publishTask.Start(ctx)
+	service.sitemapTask.Start(ctx)
+	service.rankTask.Start(ctx)
 
 	return service
 }
 
+// scheduledPublisher 定时发布任务
+type scheduledPublisher struct {
+	s *Service
+}
+
+func (p *scheduledPublisher) Run(ctx context.Context) {
+	ids, err := repoGetDueScheduledIDs(p.s.DB.WithContext(ctx), time.Now())
+	if err != nil {
+		log.Printf("获取待发布文章失败: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		ok, err := repoPublishScheduled(p.s.DB.WithContext(ctx), id)
+		if err != nil {
+			log.Printf("定时发布文章失败 id=%d: %v", id, err)
+			continue
+		}
+		if ok {
+			log.Printf("定时发布文章 id=%d", id)
+			p.s.bump(ctx, id, p.s.pop.publish, time.Now())
+
+			e := &event.Event{Type: event.ArticlePublished, ArticleID: id, Listed: true}
+			if a, err := repoGetArticleByID(p.s.DB.WithContext(ctx), id); err == nil {
+				e.Tags = splitTags(a.Tags)
+			} else {
+				log.Printf("获取文章失败 id=%d: %v", id, err)
+			}
+			p.s.bus.Publish(ctx, e)
+		}
+	}
+}
+
+// sitemapGenerator 定时重建 sitemap 和 robots.txt，文章变更时也会被触发
+type sitemapGenerator struct {
+	s *Service
+}
+
+func (g *sitemapGenerator) Run(ctx context.Context) {
+	site := g.s.site
+
+	articles, err := repoGetSitemapArticles(g.s.DB.WithContext(ctx))
+	if err != nil {
+		log.Printf("获取 sitemap 文章失败: %v", err)
+		return
+	}
+
+	urls := make([]sitemap.URL, 0, len(articles)+1)
+	urls = append(urls, sitemap.URL{Loc: site.URL + "/"})
+	for _, a := range articles {
+		urls = append(urls, sitemap.URL{Loc: g.s.articleURL(&a), LastMod: a.UpdatedAt})
+	}
+	urls[0].LastMod = sitemap.LastMod(urls)
+
+	var root []byte
+	var parts [][]byte
+	if chunks := sitemap.Split(urls); len(chunks) == 1 {
+		root, err = sitemap.URLSet(urls)
+	} else {
+		index := make([]sitemap.URL, 0, len(chunks))
+		for i, chunk := range chunks {
+			body, err := sitemap.URLSet(chunk)
+			if err != nil {
+				log.Printf("生成 sitemap 失败: %v", err)
+				return
+			}
+			parts = append(parts, body)
+			index = append(index, sitemap.URL{
+				Loc:     fmt.Sprintf("%s/sitemaps/%d.xml", site.URL, i+1),
+				LastMod: sitemap.LastMod(chunk),
+			})
+		}
+		root, err = sitemap.Index(index)
+	}
+	if err != nil {
+		log.Printf("生成 sitemap 失败: %v", err)
+		return
+	}
+
+	if err := cacheSetSitemap(ctx, g.s.RDB, root, parts); err != nil {
+		log.Printf("写入 sitemap 缓存失败: %v", err)
+	}
+	if err := cacheSetRobots(ctx, g.s.RDB, g.s.buildRobots()); err != nil {
+		log.Printf("写入 robots.txt 缓存失败: %v", err)
+	}
+}
+
+// rankMaintainer 维护热门排行：缺少重建标记时从数据库重建，纪元过旧时整体缩放分数
+type rankMaintainer struct {
+	s *Service
+}
+
+const (
+	// rankMaxHalfLives 纪元距今超过该半衰期数时缩放分数，2^64 远小于 float64 上限
+	rankMaxHalfLives = 64
+	// rankRebuildLock 重建锁的有效期，与任务超时一致
+	rankRebuildLock = 5 * time.Minute
+)
+
+func (m *rankMaintainer) Run(ctx context.Context) {
+	// 以标记而不是排行是否存在判断：Redis 数据丢失后，第一次浏览或点赞就会重新创建排行
+	built, err := cacheRankBuilt(ctx, m.s.RDB)
+	if err != nil {
+		log.Printf("检查热门排行失败: %v", err)
+		return
+	}
+	if !built {
+		m.rebuild(ctx)
+	}
+
+	for i, w := range rankWindows {
+		hl := m.s.pop.halfLives[i]
+		if hl <= 0 {
+			continue
+		}
+		n, err := cacheRankRescale(ctx, m.s.RDB, w, hl, rankMaxHalfLives)
+		if err != nil {
+			log.Printf("缩放热门排行失败 window=%s: %v", w, err)
+		} else if n > 0 {
+			log.Printf("缩放热门排行 window=%s count=%d", w, n)
+		}
+	}
+}
+
+// rebuild 以文章的累计数据重建排行，历史数据视为在发布时产生
+// 多个实例同时启动时只有抢到锁的实例重建；先写入临时排行，完成后整体替换，
+// 重建期间产生的加分会被覆盖
+func (m *rankMaintainer) rebuild(ctx context.Context) {
+	locked, err := cacheRankLock(ctx, m.s.RDB, rankRebuildLock)
+	if err != nil {
+		log.Printf("获取热门排行重建锁失败: %v", err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	articles, err := repoGetRankArticles(m.s.DB.WithContext(ctx))
+	if err != nil {
+		log.Printf("获取排行文章失败: %v", err)
+		return
+	}
+	if err := cacheRankRebuildReset(ctx, m.s.RDB, rankWindows); err != nil {
+		log.Printf("清除临时排行失败: %v", err)
+		return
+	}
+
+	p := m.s.pop
+	for _, a := range articles {
+		at := a.CreatedAt
+		if a.PublishAt != nil {
+			at = *a.PublishAt
+		}
+		weight := p.publish + p.view*float64(a.Views) + p.like*float64(a.Likes) + p.comment*float64(a.CommentCount)
+		if err := cacheRankRebuildIncr(ctx, m.s.RDB, a.ID, weight, at, rankWindows, p.halfLives); err != nil {
+			log.Printf("重建热门排行失败 id=%d: %v", a.ID, err)
+			return
+		}
+	}
+
+	if err := cacheRankSwap(ctx, m.s.RDB, rankWindows); err != nil {
+		log.Printf("替换热门排行失败: %v", err)
+		return
+	}
+	log.Printf("重建热门排行 count=%d", len(articles))
+}
+
+// bump 给文章的各窗口排行加分，at 为事件发生时间
+func (s *Service) bump(ctx context.Context, id int, weight float64, at time.Time) {
+	if err := cacheRankIncr(ctx, s.RDB, id, weight, at, rankWindows, s.pop.halfLives); err != nil {
+		log.Printf("更新热门排行失败 id=%d: %v", id, err)
+	}
+}
+
+// unrank 文章删除或不再公开时移出排行
+func (s *Service) unrank(ctx context.Context, id int) {
+	if err := cacheRankRemove(ctx, s.RDB, id, rankWindows); err != nil {
+		log.Printf("移出热门排行失败 id=%d: %v", id, err)
+	}
+}
+
+// TrackComment 评论通过或撤销审核时更新热门排行，delta 为评论数变化
+// at 为评论的发表时间，撤销时按同一时间扣分，与通过时的加分正好抵消
+func (s *Service) TrackComment(ctx context.Context, id int, delta int, at time.Time) {
+	if delta != 0 {
+		s.bump(ctx, id, s.pop.comment*float64(delta), at)
+	}
+}
+
+const (
+	viewRotateSize     = 500                // 每个浏览量批次最多包含的文章数
+	viewBatchRetention = 7 * 24 * time.Hour // 批次从 Redis 清除后记录的保留时间
+)
+
 func (s *Service) Run(ctx context.Context) {
-	ids, err := repoGetAllArticleIDs(s.DB)
+	s.flushViews(ctx)
+	s.flushReactions(ctx)
+}
+
+// flushViews 将 Redis 中的浏览量同步到数据库
+// 先把各文章的 UV 原子地转存为批次，再逐个批次写入数据库，写入失败的批次留在 Redis 中下次重试；
+// 数据库中记录已写入的批次，重试时不会重复累加
+func (s *Service) flushViews(ctx context.Context) {
+	if n, err := cacheMigrateLegacyViews(ctx, s.RDB); err != nil {
+		log.Printf("迁移遗留浏览量失败: %v", err)
+	} else if n > 0 {
+		log.Printf("已将 %d 篇文章的遗留浏览量加入待同步", n)
+	}
+
+	ids, err := cacheGetActiveViewIDs(ctx, s.RDB)
+	if err != nil {
+		log.Printf("获取待同步浏览量的文章失败: %v", err)
+	}
+
+	now := time.Now()
+	for i := 0; i < len(ids); i += viewRotateSize {
+		batch := fmt.Sprintf("%d-%d", now.UnixNano(), i/viewRotateSize)
+		if err := cacheRotateViews(ctx, s.RDB, batch, ids[i:min(i+viewRotateSize, len(ids))]); err != nil {
+			log.Printf("转存浏览量失败 batch=%s: %v", batch, err)
+		}
+	}
+
+	batches, err := cacheGetViewBatches(ctx, s.RDB)
+	if err != nil {
+		log.Printf("获取浏览量批次失败: %v", err)
+		return
+	}
+
+	pending := []string{}
+	for _, batch := range batches {
+		views, err := cacheGetViewBatch(ctx, s.RDB, batch)
+		if err != nil {
+			log.Printf("获取浏览量批次失败 batch=%s: %v", batch, err)
+			pending = append(pending, batch)
+			continue
+		}
+
+		if err := repoApplyViewBatch(s.DB.WithContext(ctx), batch, views); err != nil {
+			log.Printf("同步浏览量失败 batch=%s: %v", batch, err)
+			pending = append(pending, batch)
+			continue
+		}
+
+		if err := cacheDoneViewBatch(ctx, s.RDB, batch); err != nil {
+			log.Printf("清除浏览量批次失败 batch=%s: %v", batch, err)
+			pending = append(pending, batch)
+		}
+	}
+
+	// 仍在 Redis 中的批次保留记录，否则记录被清理后重试会重复累加
+	if err := repoPruneViewBatches(s.DB.WithContext(ctx), now.Add(-viewBatchRetention), pending); err != nil {
+		log.Printf("清理浏览量批次记录失败: %v", err)
+	}
+}
+
+// flushReactions 按数据库中的点赞和回应记录更新有变化的文章的点赞数和表情回应数
+// 计数不取自 Redis，Redis 数据丢失后不会把文章的计数覆盖为 0
+func (s *Service) flushReactions(ctx context.Context) {
+	ids, err := cacheTakeReactionDirtyIDs(ctx, s.RDB)
 	if err != nil {
+		log.Printf("获取待同步的文章回应失败: %v", err)
 		return
 	}
 
+	done := make([]int, 0, len(ids))
 	for _, id := range ids {
-		num, err := cacheGetViewUV(ctx, s.RDB, id)
-		if err != nil || num == 0 {
+		counts, err := repoCountReactions(s.DB.WithContext(ctx), id)
+		if err != nil {
+			log.Printf("获取文章回应失败 id=%d: %v", id, err)
 			continue
 		}
 
-		err = cacheDelViewUV(ctx, s.RDB, id)
+		reactions := ReactionCounts{}
+		for _, kind := range s.reactionKinds() {
+			if n := counts[kind]; n > 0 {
+				reactions[kind] = n
+			}
+		}
+		err = repoSetArticleColumns(s.DB.WithContext(ctx), id, map[string]any{
+			"likes":     counts[reactionLike],
+			"reactions": reactions,
+		})
 		if err != nil {
+			log.Printf("同步文章回应失败 id=%d: %v", id, err)
 			continue
 		}
+		done = append(done, id)
+	}
 
-		_ = repoIncrementViews(s.DB, id, num)
+	if err := cacheDoneReactionFlush(ctx, s.RDB, done...); err != nil {
+		log.Printf("清除文章回应同步标记失败: %v", err)
+	}
+	for _, id := range done {
+		s.bus.Publish(ctx, &event.Event{Type: event.ArticleUpdated, ArticleID: id, StatsOnly: true})
 	}
 }
 
 // 分页查找
 func (s *Service) GetArticlesByPage(ctx context.Context, page, pageSize int) ([]ArticleWithoutContent, int, error) {
-	articles, total, err := cacheGetArticlesByPage(ctx, s.RDB, page, pageSize)
-	if err == nil {
-		return articles, total, nil
+	p, err := s.caches.pages.GetOrLoad(ctx, pageQuery{page, pageSize}, func(ctx context.Context) (articlePage, error) {
+		articles, total, err := repoGetArticlesByPage(s.DB.WithContext(ctx), page, pageSize)
+		return articlePage{Articles: articles, Total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
 	}
+	return p.Articles, p.Total, nil
+}
 
-	if err == ErrCacheMiss {
-		articles, total, err = repoGetArticlesByPage(s.DB, page, pageSize)
+// 获取热门文章，综合浏览、点赞、评论和发布时间评分
+// window 选择衰减速度（各自的半衰期见 PopularityConfig），并不是只统计该时间段内的数据
+func (s *Service) GetArticlesByPopular(ctx context.Context, window string, limit int) ([]ArticleWithoutContent, error) {
+	return s.caches.popular.GetOrLoad(ctx, popularQuery{window, limit}, func(ctx context.Context) ([]ArticleWithoutContent, error) {
+		articles, err := s.rankedArticles(ctx, window, limit)
+		if err == ErrCacheMiss {
+			// 排行尚未建立时退化为按浏览量排序
+			articles, err = repoGetArticlesByPopular(s.DB.WithContext(ctx), limit)
+		}
+		return articles, err
+	})
+}
+
+// cachedArticle 读取文章，缓存中只有公开文章；返回的是副本，调用方可以修改
+func (s *Service) cachedArticle(ctx context.Context, id int) (*Article, error) {
+	article, err := s.caches.byID.GetOrLoad(ctx, id, func(ctx context.Context) (*Article, error) {
+		article, err := repoGetArticleByID(s.DB.WithContext(ctx), id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return article, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := *article
+	return &copied, nil
+}
+
+// rankedArticles 从排行中取前 limit 篇公开文章
+func (s *Service) rankedArticles(ctx context.Context, window string, limit int) ([]ArticleWithoutContent, error) {
+	// 多取一些，排行中可能残留已删除或不再公开的文章
+	ids, err := cacheRankTop(ctx, s.RDB, window, limit*2)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := repoGetSummariesByIDs(s.DB.WithContext(ctx), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]ArticleWithoutContent, len(found))
+	for _, a := range found {
+		byID[a.ID] = a
+	}
+	articles := make([]ArticleWithoutContent, 0, limit)
+	for _, id := range ids {
+		if a, ok := byID[id]; ok && len(articles) < limit {
+			articles = append(articles, a)
+		}
+	}
+	return articles, nil
+}
+
+// 通过ID获取文章，获取后增加views
+// viewer.Key: 用户标识，可以是用户ID或IP地址，用于防重复计数；识别为爬虫的请求不增加views
+// 已删除的文章返回 ErrArticleNotFound；非公开文章只有作者和管理员可见，
+// 匿名读者得到 ErrArticleNotFound，其他登录用户得到 ErrArticleForbidden
+func (s *Service) GetArticleByID(ctx context.Context, id int, viewer *Viewer) (*Article, error) {
+	// cache hit，缓存中只有公开文章
+	article, err := s.cachedArticle(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !article.canView(viewer) {
+		if viewer.UserID == 0 {
+			return nil, ErrArticleNotFound
+		}
+		return nil, ErrArticleForbidden
+	}
+
+	// 爬虫的浏览不计入浏览量和热门排行
+	class := s.stats.Classify(ctx, &viewer.Client)
+	if !class.Bot {
+		if added, err := cacheAddViewUV(ctx, s.RDB, id, viewer.Key); err == nil && added && article.Status == ArticlePublic {
+			s.bump(ctx, id, s.pop.view, time.Now())
+		}
+	}
+	if article.Status == ArticlePublic {
+		err := s.stats.RecordView(ctx, &analytics.Hit{
+			ArticleID: id,
+			Visitor:   viewer.Key,
+			Referrer:  viewer.Referrer,
+			UTM:       viewer.UTM,
+			Bot:       class.Signal,
+		})
 		if err != nil {
-			return nil, 0, err
+			log.Printf("记录浏览统计失败 id=%d: %v", id, err)
 		}
+	}
+
+	// 点赞和回应以 Redis 中的实时数据为准，Redis 不可用时使用数据库中的计数
+	if summary, err := s.GetReactions(ctx, id, &Viewer{}); err == nil {
+		article.Likes = summary.Likes
+		article.Reactions = summary.Reactions
+	}
+	return article, nil
+}
 
-		cacheSetArticlesByPage(ctx, s.RDB, page, pageSize, articles, total)
-		return articles, total, nil
+// 按标签分页查找
+func (s *Service) GetArticlesByTag(ctx context.Context, name string, page, pageSize int) ([]ArticleWithoutContent, int, error) {
+	p, err := s.caches.tagPages.GetOrLoad(ctx, tagQuery{name, page, pageSize}, func(ctx context.Context) (articlePage, error) {
+		articles, total, err := repoGetArticlesByTag(s.DB.WithContext(ctx), name, page, pageSize)
+		return articlePage{Articles: articles, Total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
 	}
+	return p.Articles, p.Total, nil
+}
 
-	return repoGetArticlesByPage(s.DB, page, pageSize)
+// 获取所有标签及文章数
+func (s *Service) GetTags(ctx context.Context) ([]TagWithCount, error) {
+	return s.caches.tags.GetOrLoad(ctx, struct{}{}, func(ctx context.Context) ([]TagWithCount, error) {
+		return repoGetTags(s.DB.WithContext(ctx))
+	})
 }
 
-// 获取热门文章，目前只基于view数，后续增加其他项综合判断
-func (s *Service) GetArticlesByPopular(ctx context.Context, limit int) ([]ArticleWithoutContent, error) {
-	articles, err := cacheGetArticlesByPopular(ctx, s.RDB, limit)
-	if err == nil {
-		return articles, nil
+// 全文检索文章
+func (s *Service) SearchArticles(ctx context.Context, q string, page, pageSize int) ([]SearchArticle, int, error) {
+	p, err := s.caches.search.GetOrLoad(ctx, searchQuery{q, page, pageSize}, func(ctx context.Context) (searchPage, error) {
+		articles, total, err := repoSearchArticles(s.DB.WithContext(ctx), q, page, pageSize)
+		return searchPage{Articles: articles, Total: total}, err
+	})
+	if err != nil {
+		return nil, 0, err
 	}
+	return p.Articles, p.Total, nil
+}
 
-	if err == ErrCacheMiss {
-		articles, err = repoGetArticlesByPopular(s.DB, limit)
+// InitSearchIndex 初始化全文检索列与索引
+func InitSearchIndex(db *gorm.DB) error {
+	return repoInitSearchIndex(db)
+}
+
+// BackfillTags 将历史文章的 Tags 字符串解析为标签关联
+func BackfillTags(db *gorm.DB) error {
+	n, err := repoBackfillTags(db)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("已为 %d 篇文章回填标签", n)
+	}
+	return nil
+}
+
+// 新建文章，authorID 为当前登录用户
+func (s *Service) CreateArticle(ctx context.Context, req *ArticleRequest, authorID int) (*Article, error) {
+	article := &Article{
+		Title:      req.Title,
+		Desc:       req.Desc,
+		Content:    req.Content,
+		AuthorName: req.AuthorName,
+		AuthorID:   authorID,
+		Tags:       req.Tags,
+		Cover:      req.Cover,
+		Status:     ArticleDraft,
+	}
+
+	// 未指定状态时保存为草稿，避免误发布
+	status := ArticleStatus(ArticleDraft)
+	if req.Status != nil {
+		status = *req.Status
+	}
+	publishAt, err := checkStatus(article, status, req.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+	article.Status = status
+	article.PublishAt = publishAt
+
+	if req.Slug != "" && !utils.IsSlug(req.Slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	doc, err := markdown.Process(article.Content)
+	if err != nil {
+		return nil, err
+	}
+	article.applyDocument(doc)
+
+	// 文章、slug、标签和首个修订在同一事务中写入，任一步失败都不会留下不完整的文章
+	err = s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := repoCreateArticle(tx, article); err != nil {
+			return err
+		}
+		if _, err := assignSlug(tx, article, req.Slug); err != nil {
+			return err
+		}
+		if err := repoSyncArticleTags(tx, article); err != nil {
+			return err
+		}
+		return repoCreateRevision(tx, newRevision(article))
+	})
+	if err != nil {
+		article.ID = 0
+		return nil, err
+	}
+	s.evictSlugs(ctx, article.Slug)
+
+	if article.Status == ArticlePublic {
+		s.bump(ctx, article.ID, s.pop.publish, *article.PublishAt)
+	}
+
+	e := &event.Event{Type: event.ArticleCreated, ArticleID: article.ID}
+	if article.Status == ArticlePublic {
+		e.Listed = true
+		e.Tags = splitTags(article.Tags)
+	}
+	s.bus.Publish(ctx, e)
+	return article, nil
+}
+
+// 全量更新文章
+func (s *Service) UpdateArticle(ctx context.Context, id int, req *ArticleRequest) (*Article, error) {
+	updates := map[string]any{
+		"title":      req.Title,
+		"slug":       req.Slug,
+		"desc":       req.Desc,
+		"content":    req.Content,
+		"tags":       req.Tags,
+		"cover":      req.Cover,
+		"publish_at": req.PublishAt,
+	}
+	if req.Status != nil {
+		updates["status"] = *req.Status
+	}
+
+	return s.updateArticle(ctx, id, updates)
+}
+
+// 部分更新文章
+func (s *Service) PatchArticle(ctx context.Context, id int, req *ArticlePatchRequest) (*Article, error) {
+	return s.updateArticle(ctx, id, req.updates())
+}
+
+func (s *Service) updateArticle(ctx context.Context, id int, updates map[string]any) (*Article, error) {
+	current, err := repoGetArticleByID(s.DB, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status, hasStatus := updates["status"].(ArticleStatus)
+	_, hasPublishAt := updates["publish_at"]
+	if hasStatus || hasPublishAt {
+		if !hasStatus {
+			status = current.Status
+		}
+		publishAt := current.PublishAt
+		if v, ok := updates["publish_at"].(*time.Time); ok && v != nil {
+			publishAt = v
+		}
+		publishAt, err = checkStatus(current, status, publishAt)
+		if err != nil {
+			return nil, err
+		}
+		updates["publish_at"] = publishAt
+	}
+
+	// slug 单独处理：显式指定时使用指定值，否则标题变化时重新生成
+	slug, _ := updates["slug"].(string)
+	delete(updates, "slug")
+	if slug != "" && !utils.IsSlug(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	if content, ok := updates["content"].(string); ok {
+		doc, err := markdown.Process(content)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range documentUpdates(doc) {
+			updates[k] = v
+		}
+	}
+
+	if len(updates) > 0 {
+		err := repoUpdateArticle(s.DB, id, updates)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	article, err := repoGetArticleByID(s.DB, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := updates["tags"]; ok {
+		if err := repoSyncArticleTags(s.DB, article); err != nil {
+			return nil, err
+		}
+	}
+	if slug != "" || article.Title != current.Title || article.Slug == "" {
+		old, err := assignSlug(s.DB, article, slug)
 		if err != nil {
 			return nil, err
 		}
+		if old != article.Slug {
+			s.evictSlugs(ctx, old, article.Slug)
+		}
+	}
+	if len(updates) > 0 {
+		if err := repoCreateRevision(s.DB, newRevision(article)); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &event.Event{Type: event.ArticleUpdated, ArticleID: id}
+	switch {
+	case current.Status != ArticlePublic && article.Status == ArticlePublic:
+		s.bump(ctx, id, s.pop.publish, *article.PublishAt)
+		e.Type = event.ArticlePublished
+		e.Listed = true
+	case current.Status == ArticlePublic && article.Status != ArticlePublic:
+		s.unrank(ctx, id)
+		e.Listed = true
+	}
+	// 进出公开状态或标签变化时，修改前后的标签下的列表都要失效
+	if e.Listed || article.Tags != current.Tags {
+		e.Tags = splitTags(current.Tags + "," + article.Tags)
+	}
+	s.bus.Publish(ctx, e)
+	return article, nil
+}
+
+// assignSlug 为文章设置唯一 slug，base 为空时由标题生成，旧 slug 记入历史
+// 返回修改前的 slug，由调用方在写入完成后清除新旧 slug 的缓存
+func assignSlug(db *gorm.DB, article *Article, base string) (string, error) {
+	old := article.Slug
+	if base == "" {
+		base = utils.Slugify(article.Title)
+	}
+	if base == "" {
+		base = "post-" + strconv.Itoa(article.ID)
+	}
 
-		go cacheSetArticlesByPopular(ctx, s.RDB, limit, articles)
-		return articles, nil
+	slug, err := uniqueSlug(db, base, article.ID)
+	if err != nil {
+		return old, err
+	}
+	if slug == old {
+		return old, nil
 	}
 
-	return repoGetArticlesByPopular(s.DB, limit)
+	if err := repoChangeSlug(db, article.ID, old, slug); err != nil {
+		return old, err
+	}
+	article.Slug = slug
+	return old, nil
 }
 
-// 通过ID获取文章，获取后增加views
-// userID: 用户标识，可以是用户ID或IP地址，用于防重复计数
-func (s *Service) GetArticleByID(ctx context.Context, id int, userID string) (*Article, error) {
-	// cache hit
-	article, err := cacheGetArticleByID(ctx, s.RDB, id)
-	if err == nil {
-		cacheAddViewUV(ctx, s.RDB, id, userID)
-		return article, nil
-	} else {
-		article, err = repoGetArticleByID(s.DB, id)
+// evictSlugs 清除 slug 到文章ID的缓存，忽略空 slug
+func (s *Service) evictSlugs(ctx context.Context, slugs ...string) {
+	slugs = slices.DeleteFunc(slugs, func(slug string) bool { return slug == "" })
+	if err := s.caches.bySlug.Delete(ctx, slugs...); err != nil {
+		log.Printf("清除 slug 缓存失败 slugs=%v: %v", slugs, err)
+	}
+}
+
+// uniqueSlug 在 base 后追加 -2、-3... 直到不与其他文章冲突，追加后仍不超过 slug 最大长度
+func uniqueSlug(db *gorm.DB, base string, id int) (string, error) {
+	slug := base
+	for i := 2; ; i++ {
+		taken, err := repoSlugTaken(db, slug, id)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+		slug = utils.SuffixSlug(base, i)
+	}
+}
+
+// 通过 slug 获取文章，slug 为曾用地址时返回跳转信息
+func (s *Service) GetArticleBySlug(ctx context.Context, slug string, viewer *Viewer) (*Article, *SlugRedirect, error) {
+	id, err := s.caches.bySlug.GetOrLoad(ctx, slug, func(ctx context.Context) (int, error) {
+		return repoGetArticleIDBySlug(s.DB.WithContext(ctx), slug)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		redirect, err := repoGetSlugRedirect(s.DB.WithContext(ctx), slug)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrArticleNotFound
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// 读者看不到目标文章时按不存在处理，不暴露草稿、私密文章的新地址
+		target := Article{Status: redirect.Status, AuthorID: redirect.AuthorID}
+		if !target.canView(viewer) {
+			return nil, nil, ErrArticleNotFound
+		}
+		return nil, redirect, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	article, err := s.GetArticleByID(ctx, id, viewer)
+	return article, nil, err
+}
+
+// 获取订阅源，tag 为空时为全站订阅
+func (s *Service) GetFeed(ctx context.Context, format, tag string) (*FeedBody, error) {
+	return s.caches.feeds.GetOrLoad(ctx, feedQuery{format, tag}, func(ctx context.Context) (*FeedBody, error) {
+		return s.buildFeed(ctx, format, tag)
+	})
+}
+
+func (s *Service) buildFeed(ctx context.Context, format, tag string) (*FeedBody, error) {
+	// 标签订阅源按标签名缓存，不存在的标签不生成，避免任意标签名占用缓存
+	if tag != "" {
+		ok, err := repoTagExists(s.DB.WithContext(ctx), tag)
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			return nil, ErrFeedNotFound
+		}
+	}
+
+	limit := s.site.FeedLimit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	articles, err := repoGetFeedArticles(s.DB.WithContext(ctx), tag, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &feed.Feed{
+		Title:       s.site.Title,
+		Link:        s.site.URL,
+		FeedLink:    s.site.URL + feedPath(format, tag),
+		Description: s.site.Description,
+		Author:      s.site.Author,
+		Language:    s.site.Language,
+	}
+	if tag != "" {
+		f.Title = s.site.Title + " - " + tag
+	}
+
+	ids := make([]int, 0, len(articles))
+	for _, a := range articles {
+		ids = append(ids, a.ID)
+		if a.UpdatedAt.After(f.Updated) {
+			f.Updated = a.UpdatedAt
+		}
+
+		published := a.CreatedAt
+		if a.PublishAt != nil {
+			published = *a.PublishAt
+		}
+		f.Items = append(f.Items, feed.Item{
+			ID:          s.articleGUID(&a),
+			Title:       a.Title,
+			Link:        s.articleURL(&a),
+			Summary:     a.Desc,
+			ContentHTML: a.ContentHTML,
+			Author:      a.AuthorName,
+			Tags:        splitTags(a.Tags),
+			Published:   published,
+			Updated:     a.UpdatedAt,
+		})
+	}
+
+	return renderFeed(format, f, ids)
+}
+
+// renderFeed 按格式输出订阅源，ETag 取输出内容的摘要，内容不变时保持稳定
+func renderFeed(format string, f *feed.Feed, ids []int) (*FeedBody, error) {
+	var body []byte
+	var err error
+	switch format {
+	case FeedRSS:
+		body, err = feed.RSS(f)
+	case FeedAtom:
+		body, err = feed.Atom(f)
+	case FeedJSON:
+		body, err = feed.JSON(f)
+	default:
+		return nil, ErrInvalidFeed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum(body)
+	return &FeedBody{
+		Body:         body,
+		ETag:         `"` + hex.EncodeToString(sum[:]) + `"`,
+		LastModified: f.Updated,
+		ArticleIDs:   ids,
+	}, nil
+}
+
+// 获取 sitemap，part 为 0 时为 /sitemap.xml
+// 只读缓存，未命中时触发重建，保证爬虫请求不会直接访问数据库
+func (s *Service) GetSitemap(ctx context.Context, part int) ([]byte, error) {
+	data, err := cacheGetSitemap(ctx, s.RDB, part)
+	if err == ErrCacheMiss {
+		if part == 0 {
+			s.sitemapTask.Trigger()
+		}
+		return nil, ErrSitemapNotFound
+	}
+	return data, err
+}
+
+// 获取 robots.txt，只依赖配置
+func (s *Service) GetRobots(ctx context.Context) []byte {
+	data, err := cacheGetRobots(ctx, s.RDB)
+	if err == nil {
+		return data
+	}
+
+	data = s.buildRobots()
+	cacheSetRobots(ctx, s.RDB, data)
+	return data
+}
+
+func (s *Service) buildRobots() []byte {
+	groups := make([]sitemap.RobotsGroup, 0, len(s.site.Robots))
+	for _, r := range s.site.Robots {
+		groups = append(groups, sitemap.RobotsGroup{
+			UserAgents: r.UserAgents,
+			Allow:      r.Allow,
+			Disallow:   r.Disallow,
+			CrawlDelay: r.CrawlDelay,
+		})
+	}
+	if len(groups) == 0 {
+		groups = append(groups, sitemap.RobotsGroup{Disallow: []string{"/api/"}})
+	}
+
+	return sitemap.Robots(groups, s.site.URL+"/sitemap.xml")
+}
+
+// articleURL 按 site.articlePath 生成文章的对外地址
+func (s *Service) articleURL(a *Article) string {
+	path := s.site.ArticlePath
+	if path == "" {
+		path = "/article/{slug}"
+	}
+	slug := a.Slug
+	if slug == "" {
+		slug = strconv.Itoa(a.ID)
+	}
+	path = strings.ReplaceAll(path, "{slug}", slug)
+	path = strings.ReplaceAll(path, "{id}", strconv.Itoa(a.ID))
+	return s.site.URL + path
+}
+
+// articleGUID 订阅源条目的唯一标识，使用由ID构成的 tag URI（RFC 4151），修改 slug 后不变
+func (s *Service) articleGUID(a *Article) string {
+	host := "localhost"
+	if u, err := url.Parse(s.site.URL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Sprintf("tag:%s,%s:article:%d", host, a.CreatedAt.UTC().Format("2006-01-02"), a.ID)
+}
+
+// feedPath 订阅源路径，与 Handler 注册的路由一致
+func feedPath(format, tag string) string {
+	name := map[string]string{
+		FeedRSS:  "feed.xml",
+		FeedAtom: "atom.xml",
+		FeedJSON: "feed.json",
+	}[format]
+	if tag == "" {
+		return "/" + name
+	}
+	return "/tags/" + url.PathEscape(tag) + "/" + name
+}
+
+// BackfillRendered 为历史文章渲染 HTML、生成目录和字数统计
+func BackfillRendered(db *gorm.DB) error {
+	articles, err := repoGetArticlesNotRendered(db)
+	if err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		doc, err := markdown.Process(article.Content)
+		if err != nil {
+			return err
+		}
+		if err := repoSetArticleColumns(db, article.ID, documentUpdates(doc)); err != nil {
+			return err
+		}
+	}
+	if len(articles) > 0 {
+		log.Printf("已为 %d 篇文章渲染正文", len(articles))
+	}
+	return nil
+}
+
+// BackfillSlugs 为历史文章生成 slug
+func BackfillSlugs(db *gorm.DB) error {
+	articles, err := repoGetArticlesWithoutSlug(db)
+	if err != nil {
+		return err
+	}
+
+	for _, article := range articles {
+		base := utils.Slugify(article.Title)
+		if base == "" {
+			base = "post-" + strconv.Itoa(article.ID)
+		}
+		slug, err := uniqueSlug(db, base, article.ID)
+		if err != nil {
+			return err
+		}
+		if err := repoChangeSlug(db, article.ID, "", slug); err != nil {
+			return err
+		}
+	}
+	if len(articles) > 0 {
+		log.Printf("已为 %d 篇文章生成 slug", len(articles))
+	}
+	return nil
+}
+
+// 获取文章修订列表
+func (s *Service) GetRevisions(ctx context.Context, id int) ([]ArticleRevision, error) {
+	return repoGetRevisions(s.DB.WithContext(ctx), id)
+}
+
+// 获取单个修订
+func (s *Service) GetRevision(ctx context.Context, id, revisionID int) (*ArticleRevision, error) {
+	revision, err := repoGetRevision(s.DB.WithContext(ctx), id, revisionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, err
+}
+
+// 比较两个修订
+func (s *Service) DiffRevisions(ctx context.Context, id, from, to int) (*RevisionDiff, error) {
+	a, err := s.GetRevision(ctx, id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.GetRevision(ctx, id, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiff{
+		From: from,
+		To:   to,
+		Diff: utils.UnifiedDiff(
+			"revision "+strconv.Itoa(from),
+			"revision "+strconv.Itoa(to),
+			a.text(), b.text(), 3,
+		),
+	}, nil
+}
+
+// 将修订恢复为当前版本，与普通更新一样生成新修订并清除缓存
+func (s *Service) RestoreRevision(ctx context.Context, id, revisionID int) (*Article, error) {
+	revision, err := s.GetRevision(ctx, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateArticle(ctx, id, map[string]any{
+		"title":   revision.Title,
+		"desc":    revision.Desc,
+		"content": revision.Content,
+		"tags":    revision.Tags,
+	})
+}
+
+// CheckCommentable 只有存在且公开的文章允许评论
+func (s *Service) CheckCommentable(ctx context.Context, id int) error {
+	return s.checkPublic(ctx, id)
+}
+
+// 点赞或取消点赞，同一读者（用户ID或IP）只计一次
+func (s *Service) Like(ctx context.Context, id int, viewer *Viewer, like bool) (*ReactionSummary, error) {
+	if err := s.checkPublic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	changed, err := s.setReaction(ctx, id, reactionLike, viewer.Key, like)
+	if err != nil {
+		return nil, err
+	}
+	// 取消点赞不扣分：按当前时间扣分会多于当初的加分；同一读者只在第一次点赞时加分，避免反复点赞刷分
+	if changed && like {
+		first, err := cacheRankFirstLike(ctx, s.RDB, id, viewer.Key)
+		if err != nil {
+			log.Printf("记录点赞读者失败 id=%d: %v", id, err)
+		}
+		if first {
+			s.bump(ctx, id, s.pop.like, time.Now())
+		}
+	}
+	return s.GetReactions(ctx, id, viewer)
+}
+
+// 添加或取消表情回应
+func (s *Service) React(ctx context.Context, id int, kind string, viewer *Viewer, on bool) (*ReactionSummary, error) {
+	if !slices.Contains(s.reactionKinds(), kind) {
+		return nil, ErrInvalidReaction
+	}
+	if err := s.checkPublic(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.setReaction(ctx, id, kind, viewer.Key, on); err != nil {
+		return nil, err
+	}
+	return s.GetReactions(ctx, id, viewer)
+}
+
+// 获取文章的点赞和表情回应，以及当前读者的状态
+func (s *Service) GetReactions(ctx context.Context, id int, viewer *Viewer) (*ReactionSummary, error) {
+	if err := s.loadReactions(ctx, id); err != nil {
+		return nil, err
+	}
+	return cacheGetReactions(ctx, s.RDB, id, s.reactionKinds(), viewer.Key)
+}
+
+// setReaction 先写数据库再更新 Redis 集合，返回 Redis 集合是否发生变化
+func (s *Service) setReaction(ctx context.Context, id int, kind, member string, add bool) (bool, error) {
+	if err := s.loadReactions(ctx, id); err != nil {
+		return false, err
+	}
+
+	r := &Reaction{ArticleID: id, Kind: kind, Member: member}
+	if _, err := repoSetReaction(s.DB.WithContext(ctx), r, add); err != nil {
+		return false, err
+	}
+	return cacheSetMember(ctx, s.RDB, id, reactionKey(id, kind), member, add)
+}
+
+// loadReactions Redis 中没有已对齐标记时（首次访问或数据丢失），将数据库和 Redis 中的记录互相补齐
+// Redis 中有而数据库中没有的是旧版本只写 Redis 时留下的记录
+func (s *Service) loadReactions(ctx context.Context, id int) error {
+	loaded, err := cacheReactionsLoaded(ctx, s.RDB, id)
+	if err != nil || loaded {
+		return err
+	}
+
+	cached, err := cacheGetReactionMembers(ctx, s.RDB, id, s.reactionKinds())
+	if err != nil {
+		return err
+	}
+	if err := repoAddReactions(s.DB.WithContext(ctx), cached); err != nil {
+		return err
+	}
+	stored, err := repoGetReactions(s.DB.WithContext(ctx), id)
+	if err != nil {
+		return err
+	}
+	if err := cacheLoadReactions(ctx, s.RDB, id, stored); err != nil {
+		return err
+	}
+
+	// 补入数据库的旧记录需要同步到文章的计数
+	if len(cached) > 0 {
+		if err := s.RDB.SAdd(ctx, ArticleReactionDirtyKey(), id).Err(); err != nil {
+			log.Printf("标记文章回应待同步失败 id=%d: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// 收藏或取消收藏
+func (s *Service) Bookmark(ctx context.Context, id, userID int, add bool) error {
+	if !add {
+		return repoDeleteBookmark(s.DB.WithContext(ctx), userID, id)
+	}
+
+	if err := s.checkPublic(ctx, id); err != nil {
+		return err
+	}
+	return repoAddBookmark(s.DB.WithContext(ctx), userID, id)
+}
+
+// 分页获取用户的收藏
+func (s *Service) GetBookmarks(ctx context.Context, userID, page, pageSize int) ([]ArticleWithoutContent, int, error) {
+	return repoGetBookmarks(s.DB.WithContext(ctx), userID, page, pageSize)
+}
+
+func (s *Service) reactionKinds() []string {
+	if len(s.conf.Reactions) > 0 {
+		return s.conf.Reactions
+	}
+	return defaultReactions
+}
+
+// checkPublic 文章存在且公开
+func (s *Service) checkPublic(ctx context.Context, id int) error {
+	article, err := s.cachedArticle(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if article.Status != ArticlePublic {
+		return ErrArticleNotFound
+	}
+	return nil
+}
+
+// SetCommentCount 更新文章的评论数，由评论模块在审核状态变化后调用
+// 评论数不算作内容修改，不更新 updated_at，也不触发 sitemap 重建
+func (s *Service) SetCommentCount(ctx context.Context, id int, count int) error {
+	if err := repoSetArticleColumns(s.DB, id, map[string]any{"comment_count": count}); err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, &event.Event{Type: event.ArticleUpdated, ArticleID: id, StatsOnly: true})
+	return nil
+}
+
+// 删除文章（软删除）
+func (s *Service) DeleteArticle(ctx context.Context, id int) error {
+	current, err := repoGetArticleByID(s.DB, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = repoDeleteArticle(s.DB, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	s.unrank(ctx, id)
+	e := &event.Event{Type: event.ArticleDeleted, ArticleID: id}
+	if current.Status == ArticlePublic {
+		e.Listed = true
+		e.Tags = splitTags(current.Tags)
+	}
+	s.bus.Publish(ctx, e)
+	return nil
+}
+
+// checkStatus 校验状态转换，返回应保存的发布时间
+// 定时发布要求发布时间晚于当前；转为公开时若无发布时间或发布时间在未来，则以当前时间为准
+func checkStatus(current *Article, status ArticleStatus, publishAt *time.Time) (*time.Time, error) {
+	if !current.Status.CanTransitionTo(status) {
+		return nil, ErrInvalidTransition
+	}
+
+	now := time.Now()
+	switch status {
+	case ArticleScheduled:
+		if publishAt == nil || !publishAt.After(now) {
+			return nil, ErrInvalidPublishAt
+		}
+	case ArticlePublic:
+		if publishAt == nil || publishAt.After(now) {
+			publishAt = &now
+		}
+	}
+	return publishAt, nil
+}
+
+// 生成草稿预览分享链接，ttl <= 0 时使用默认有效期
+func (s *Service) CreatePreviewLink(ctx context.Context, id int, ttl time.Duration) (*PreviewLink, error) {
+	article, err := repoGetArticleByID(s.DB.WithContext(ctx), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !previewable(article.Status) {
+		return nil, ErrArticleForbidden
+	}
+
+	if ttl <= 0 {
+		ttl = s.conf.PreviewTTL
+	}
+	if ttl <= 0 {
+		ttl = 72 * time.Hour
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	return &PreviewLink{
+		Token:     signPreview(s.conf.PreviewSecret, id, expiresAt),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// 通过预览链接获取草稿或定时发布的文章，不计入浏览
+// 私密等其他状态的文章不能通过预览链接访问
+func (s *Service) GetArticlePreview(ctx context.Context, token string) (*Article, error) {
+	id, err := verifyPreview(s.conf.PreviewSecret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	article, err := repoGetArticleByID(s.DB.WithContext(ctx), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrArticleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !previewable(article.Status) {
+		return nil, ErrArticleNotFound
+	}
+	return article, nil
+}
+
+// previewable 只有尚未发布的文章可以生成和访问预览链接
+func previewable(status ArticleStatus) bool {
+	return status == ArticleDraft || status == ArticleScheduled
+}
+
+// signPreview 预览 token 格式：<id>.<过期时间戳>.<HMAC-SHA256 签名>
+func signPreview(secret string, id int, expiresAt time.Time) string {
+	payload := strconv.Itoa(id) + "." + strconv.FormatInt(expiresAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func verifyPreview(secret, token string, now time.Time) (int, error) {
+	// 未配置密钥时任何人都能签发 token，一律拒绝
+	if secret == "" {
+		return 0, ErrInvalidPreview
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrInvalidPreview
+	}
 
-		// sync write-back to the DB and increasing views
-		cacheAddViewUV(ctx, s.RDB, id, userID)
-		cacheSetArticleByID(ctx, s.RDB, id, article)
-		return article, nil
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, ErrInvalidPreview
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, ErrInvalidPreview
 	}
+
+	expected := signPreview(secret, id, time.Unix(exp, 0))
+	if !hmac.Equal([]byte(expected), []byte(token)) {
+		return 0, ErrInvalidPreview
+	}
+	if now.Unix() > exp {
+		return 0, ErrInvalidPreview
+	}
+	return id, nil
 }
 
-// Todo 按tags找文章
-func (s *Service) GetArticlesByTag(limit int) ([]Article, error) {
-	return nil, nil
+// onArticleEvent 文章变更后清除缓存：详情精确删除，列表按分组失效，失败只记录日志
+// 只在发布事件的实例上执行，Redis 缓存由各实例共享
+func (s *Service) onArticleEvent(ctx context.Context, e *event.Event) {
+	if err := s.caches.byID.Delete(ctx, e.ArticleID); err != nil {
+		log.Printf("清除文章缓存失败 id=%d: %v", e.ArticleID, err)
+	}
+
+	if _, err := cache.InvalidateGroups(ctx, s.RDB, eventGroups(e)...); err != nil {
+		log.Printf("清除文章列表缓存失败 id=%d: %v", e.ArticleID, err)
+	}
+
+	// sitemap 不删除缓存，直接后台重建
+	if !e.StatsOnly {
+		s.sitemapTask.Trigger()
+	}
+}
+
+// onArticleEventLocal 清除进程内缓存，每个实例都会执行
+func (s *Service) onArticleEventLocal(ctx context.Context, e *event.Event) {
+	s.caches.evict(e.ArticleID, eventGroups(e))
+}
+
+// eventGroups 文章事件影响的缓存分组
+func eventGroups(e *event.Event) []string {
+	groups := []string{ArticleGroup(e.ArticleID)}
+	if e.Listed {
+		groups = append(groups, ArticleListGroup())
+	}
+	for _, tag := range e.Tags {
+		groups = append(groups, ArticleTagGroup(tag))
+	}
+	if e.Listed || len(e.Tags) > 0 {
+		groups = append(groups, ArticleTagsGroup())
+	}
+	return groups
 }
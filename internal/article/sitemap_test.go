@@ -0,0 +1,45 @@
+package article
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheSetSitemapDropsStaleParts(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+
+	parts := func(n int) [][]byte {
+		p := make([][]byte, n)
+		for i := range p {
+			p[i] = []byte{byte('a' + i)}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name  string
+		parts int
+	}{
+		{"三个分片", 3},
+		{"减少到两个", 2},
+		{"不再分片", 0},
+		{"重新分片", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cacheSetSitemap(ctx, rdb, []byte("root"), parts(tt.parts)); err != nil {
+				t.Fatal(err)
+			}
+			for i := 1; i <= 3; i++ {
+				_, err := cacheGetSitemap(ctx, rdb, i)
+				if found := err == nil; found != (i <= tt.parts) {
+					t.Errorf("part %d found = %v, want %v", i, found, i <= tt.parts)
+				}
+			}
+			if root, err := cacheGetSitemap(ctx, rdb, 0); err != nil || string(root) != "root" {
+				t.Errorf("root = %q, %v", root, err)
+			}
+		})
+	}
+}
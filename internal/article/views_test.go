@@ -0,0 +1,46 @@
+package article
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCacheMigrateLegacyViews(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+
+	// 旧版本留下的 UV，不在待同步集合中
+	rdb.PFAdd(ctx, ArticleViewKey(1), "a", "b")
+	rdb.PFAdd(ctx, ArticleViewKey(2), "c")
+
+	n, err := cacheMigrateLegacyViews(ctx, rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("migrated = %d, want 2", n)
+	}
+
+	// 只执行一次
+	if n, _ := cacheMigrateLegacyViews(ctx, rdb); n != 0 {
+		t.Fatalf("second migration = %d, want 0", n)
+	}
+
+	ids, err := cacheGetActiveViewIDs(ctx, rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cacheRotateViews(ctx, rdb, "b1", ids); err != nil {
+		t.Fatal(err)
+	}
+	views, err := cacheGetViewBatch(ctx, rdb, "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if views[1] != 2 || views[2] != 1 {
+		t.Errorf("views = %v, want map[1:2 2:1]", views)
+	}
+	if rdb.Exists(ctx, ArticleViewKey(1), ArticleViewKey(2)).Val() != 0 {
+		t.Error("legacy UV keys not cleared after rotation")
+	}
+}
@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 识别为爬虫的信号
+const (
+	SignalEmptyUA  = "empty-ua" // 没有 User-Agent
+	SignalCrawler  = "crawler"  // 已知爬虫
+	SignalPattern  = "pattern"  // 命中 UA 规则
+	SignalHeadless = "headless" // 无头浏览器
+	SignalRate     = "rate"     // 单个 IP 请求过于频繁
+)
+
+// knownCrawlers 常见搜索引擎、社交平台、SEO 工具和 AI 爬虫的 UA 关键字，小写
+var knownCrawlers = []string{
+	"googlebot", "google-inspectiontool", "adsbot-google", "mediapartners-google",
+	"bingbot", "bingpreview", "msnbot", "baiduspider", "yandex", "duckduckbot", "slurp",
+	"sogou", "360spider", "yisouspider", "bytespider", "petalbot", "applebot", "seznambot",
+	"facebookexternalhit", "facebot", "twitterbot", "linkedinbot", "slackbot", "discordbot",
+	"telegrambot", "whatsapp", "pinterestbot", "redditbot",
+	"ahrefsbot", "semrushbot", "mj12bot", "dotbot", "blexbot", "dataforseobot", "screaming frog",
+	"gptbot", "chatgpt-user", "oai-searchbot", "claudebot", "anthropic-ai", "perplexitybot",
+	"ccbot", "amazonbot", "meta-externalagent", "imagesiftbot",
+}
+
+// defaultPatterns 通用的程序化客户端特征
+// 名称以 bot 结尾的只匹配产品标识（如 "ExampleBot/1.0"），避免误判型号以 bot 结尾的手机（如 "CUBOT X19"）
+var defaultPatterns = []string{
+	`\w+bot/`, `crawl`, `spider`, `scrap`, `fetcher`,
+	`^curl/`, `^wget/`, `python-requests`, `python-urllib`, `aiohttp`, `httpx`,
+	`go-http-client`, `^java/`, `okhttp`, `apache-httpclient`, `libwww-perl`, `node-fetch`, `axios/`,
+	`postmanruntime`, `insomnia`,
+}
+
+// headlessMarkers 无头浏览器和自动化工具在 UA 中留下的特征
+var headlessMarkers = []string{
+	"headlesschrome", "phantomjs", "puppeteer", "playwright", "selenium", "webdriver", "slimerjs",
+}
+
+// browserHeaders 浏览器总会发送的请求头，单独缺少一个不足以判定（代理可能删除），
+// 缺少的个数达到 headerThreshold 时判定为脚本
+var browserHeaders = []string{"Accept-Language", "Accept", "Sec-Fetch-Mode"}
+
+const headerThreshold = 2
+
+// Request 待识别的请求
+type Request struct {
+	UserAgent string
+	IP        string
+	Header    http.Header
+}
+
+// Result 识别结果，Bot 为 false 时其余字段为空
+type Result struct {
+	Bot    bool
+	Signal string
+	Name   string // 命中的爬虫名称或规则
+}
+
+// Rules 识别规则
+type Rules struct {
+	Crawlers   []string      // 内置列表之外的爬虫 UA 关键字，不区分大小写
+	Patterns   []string      // 内置规则之外的 UA 正则，不区分大小写
+	Headless   bool          // 是否检测无头浏览器特征
+	RateLimit  int           // 单个 IP 每个窗口内最多的请求数，0 表示不限制
+	RateWindow time.Duration // 计数窗口，默认 1 分钟
+}
+
+// Detector 按 UA、无头浏览器特征和请求频率识别爬虫
+type Detector struct {
+	rdb *redis.Client
+
+	crawlers   []string
+	patterns   []*regexp.Regexp
+	headless   bool
+	rateLimit  int
+	rateWindow time.Duration
+}
+
+func NewDetector(rdb *redis.Client, rules Rules) *Detector {
+	d := &Detector{
+		rdb:        rdb,
+		crawlers:   append([]string{}, knownCrawlers...),
+		headless:   rules.Headless,
+		rateLimit:  rules.RateLimit,
+		rateWindow: rules.RateWindow,
+	}
+	if d.rateWindow <= 0 {
+		d.rateWindow = time.Minute
+	}
+
+	for _, c := range rules.Crawlers {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			d.crawlers = append(d.crawlers, c)
+		}
+	}
+	for _, p := range append(append([]string{}, defaultPatterns...), rules.Patterns...) {
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			log.Printf("忽略无效的爬虫规则 %q: %v", p, err)
+			continue
+		}
+		d.patterns = append(d.patterns, re)
+	}
+	return d
+}
+
+// Classify 依次检查 UA、已知爬虫、UA 规则、无头浏览器特征和请求频率，命中任一即判定为爬虫
+// 频率计数失败时只记录日志，按正常访客处理
+func (d *Detector) Classify(ctx context.Context, r *Request) Result {
+	ua := strings.TrimSpace(r.UserAgent)
+	if ua == "" {
+		return Result{Bot: true, Signal: SignalEmptyUA}
+	}
+
+	lower := strings.ToLower(ua)
+	for _, c := range d.crawlers {
+		if strings.Contains(lower, c) {
+			return Result{Bot: true, Signal: SignalCrawler, Name: c}
+		}
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(ua) {
+			return Result{Bot: true, Signal: SignalPattern, Name: re.String()}
+		}
+	}
+
+	if d.headless {
+		if name, ok := headless(lower, r.Header); ok {
+			return Result{Bot: true, Signal: SignalHeadless, Name: name}
+		}
+	}
+
+	if d.rateLimit > 0 && r.IP != "" {
+		n, err := d.hit(ctx, r.IP)
+		if err != nil {
+			log.Printf("爬虫频率计数失败 ip=%s: %v", r.IP, err)
+		} else if n > int64(d.rateLimit) {
+			return Result{Bot: true, Signal: SignalRate, Name: r.IP}
+		}
+	}
+
+	return Result{}
+}
+
+// headless 检查 UA 和请求头中的无头浏览器特征
+// UA 和 Sec-Ch-Ua 中的特征直接判定；缺少浏览器请求头只计分，见 browserHeaders
+func headless(lowerUA string, header http.Header) (string, bool) {
+	for _, m := range headlessMarkers {
+		if strings.Contains(lowerUA, m) {
+			return m, true
+		}
+	}
+	if header == nil {
+		return "", false
+	}
+	if strings.Contains(strings.ToLower(header.Get("Sec-Ch-Ua")), "headless") {
+		return "sec-ch-ua", true
+	}
+
+	missing := []string{}
+	for _, h := range browserHeaders {
+		if header.Get(h) == "" {
+			missing = append(missing, strings.ToLower(h))
+		}
+	}
+	if len(missing) >= headerThreshold {
+		return "missing:" + strings.Join(missing, ","), true
+	}
+	return "", false
+}
+
+// hit 累加 IP 在当前窗口内的请求数
+func (d *Detector) hit(ctx context.Context, ip string) (int64, error) {
+	window := time.Now().Truncate(d.rateWindow).Unix()
+	key := BotRateKey(ip, window)
+
+	pipe := d.rdb.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, d.rateWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
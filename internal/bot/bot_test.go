@@ -0,0 +1,94 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+// browser 浏览器发出的 fetch 请求通常带有的请求头
+func browser() http.Header {
+	return http.Header{
+		"Accept":          {"*/*"},
+		"Accept-Language": {"zh-CN,zh;q=0.9,en;q=0.8"},
+		"Sec-Fetch-Mode":  {"cors"},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	d := NewDetector(nil, Rules{Headless: true})
+
+	without := func(names ...string) http.Header {
+		h := browser()
+		for _, n := range names {
+			h.Del(n)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name   string
+		ua     string
+		header http.Header
+		signal string // 为空表示正常访客
+	}{
+		{"Chrome 桌面", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", browser(), ""},
+		{"Firefox", "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0", browser(), ""},
+		{"iOS Safari", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", browser(), ""},
+		{"型号以 bot 结尾的手机", "Mozilla/5.0 (Linux; Android 10; CUBOT X19) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36", browser(), ""},
+		{"型号以 bot 结尾的手机 2", "Mozilla/5.0 (Linux; Android 11; KINGKONG 7 Build/RP1A; Cubot) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0 Mobile Safari/537.36", browser(), ""},
+		{"旧版 Safari 不带 Sec-Fetch", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.6 Safari/605.1.15", without("Sec-Fetch-Mode"), ""},
+		{"代理去掉了 Accept-Language", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", without("Accept-Language"), ""},
+
+		{"空 UA", "", browser(), SignalEmptyUA},
+		{"Googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", browser(), SignalCrawler},
+		{"Bingbot", "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", browser(), SignalCrawler},
+		{"GPTBot", "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)", nil, SignalCrawler},
+		{"未知的 bot 产品标识", "Mozilla/5.0 (compatible; ExampleBot/1.0; +https://example.com/bot)", browser(), SignalPattern},
+		{"爬虫关键字", "SiteCrawler 3.1", browser(), SignalPattern},
+		{"curl", "curl/8.5.0", nil, SignalPattern},
+		{"python-requests", "python-requests/2.32.3", nil, SignalPattern},
+		{"Go 客户端", "Go-http-client/1.1", nil, SignalPattern},
+
+		{"HeadlessChrome", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36", browser(), SignalHeadless},
+		{"Sec-Ch-Ua 标记为无头", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			func() http.Header { h := browser(); h.Set("Sec-Ch-Ua", `"HeadlessChrome";v="126"`); return h }(), SignalHeadless},
+		{"伪装浏览器 UA 的脚本", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", without("Accept-Language", "Sec-Fetch-Mode"), SignalHeadless},
+		{"没有任何请求头", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", http.Header{}, SignalHeadless},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := d.Classify(context.Background(), &Request{UserAgent: tt.ua, Header: tt.header})
+			if got.Signal != tt.signal || got.Bot != (tt.signal != "") {
+				t.Errorf("Classify() = %+v, want signal %q", got, tt.signal)
+			}
+		})
+	}
+}
+
+func TestClassifyHeadlessDisabled(t *testing.T) {
+	d := NewDetector(nil, Rules{})
+	ua := "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36"
+	if got := d.Classify(context.Background(), &Request{UserAgent: ua, Header: http.Header{}}); got.Bot {
+		t.Errorf("Classify() = %+v, want not bot when headless detection is off", got)
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	d := NewDetector(nil, Rules{Crawlers: []string{" MyMonitor "}, Patterns: []string{`^uptime`, `[invalid`}})
+	tests := []struct {
+		ua     string
+		signal string
+	}{
+		{"Mozilla/5.0 (compatible; mymonitor 1.0)", SignalCrawler},
+		{"Uptime-Kuma/1.23", SignalPattern},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/126.0.0.0 Safari/537.36", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ua, func(t *testing.T) {
+			if got := d.Classify(context.Background(), &Request{UserAgent: tt.ua}); got.Signal != tt.signal {
+				t.Errorf("Classify() = %+v, want signal %q", got, tt.signal)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package bot
+
+import (
+	"fmt"
+)
+
+// BotRateKey 某个 IP 在一个计数窗口内的请求数，window 为窗口起始的 Unix 秒
+func BotRateKey(ip string, window int64) string {
+	return fmt.Sprintf("Bot:Rate:%s:%d", ip, window)
+}
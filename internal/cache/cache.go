@@ -0,0 +1,335 @@
+package cache
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// TTLPolicy 按缓存 key 决定过期时间
+type TTLPolicy func(key string) time.Duration
+
+// FixedTTL 所有 key 使用相同的过期时间
+func FixedTTL(ttl time.Duration) TTLPolicy {
+	return func(string) time.Duration {
+		return ttl
+	}
+}
+
+// Config 缓存的声明，除 Name、Key、TTL 外均可省略
+type Config[K comparable, V any] struct {
+	Name  string         // 缓存名称，用于统计和日志
+	Key   func(K) string // 查询参数到 Redis key 的映射
+	TTL   TTLPolicy
+	Codec Codec // 默认 JSON
+
+	// Jitter 过期时间随机浮动的比例，如 0.1 为 ±10%，避免同一时刻写入的缓存同时过期
+	Jitter float64
+	// Beta 提前刷新的激进程度，0 表示不提前刷新，1 为 XFetch 算法的推荐值
+	Beta float64
+
+	// Negative 回源返回该错误时写入空标记，NegativeTTL 内直接返回该错误，不再回源
+	Negative    error
+	NegativeTTL time.Duration
+
+	// Cacheable 返回 false 的结果不写入缓存，默认全部写入
+	Cacheable func(V) bool
+	// Groups 缓存所属的分组，InvalidateGroups 按分组批量失效，如某篇文章出现在哪些列表中
+	Groups func(K, V) []string
+
+	// L1 进程内缓存，Size 或 TTL 为 0 时不启用
+	// 命中时直接返回同一个值，调用方不得修改；其他实例的修改通过 Evict、EvictGroups 同步
+	L1 L1Config
+
+	Metrics Metrics
+}
+
+// L1Config 进程内缓存的容量和过期时间
+type L1Config struct {
+	Size int           // 最多缓存的条目数
+	TTL  time.Duration // 不超过 Redis 中的剩余有效期
+}
+
+// Cache 基于 Redis 的 cache-aside 缓存，可选在前面加一层进程内 LRU 缓存
+// 未命中时同一个 key 只有一个请求回源，其余等待并共享结果；
+// 缓存中记录回源耗时，临近过期时按 XFetch 算法概率性地在后台提前刷新
+type Cache[K comparable, V any] struct {
+	rdb    *redis.Client
+	conf   Config[K, V]
+	l1     *local[V]
+	flight singleflight.Group
+}
+
+func New[K comparable, V any](rdb *redis.Client, conf Config[K, V]) *Cache[K, V] {
+	if conf.Codec == nil {
+		conf.Codec = JSON
+	}
+	if conf.Metrics == nil {
+		conf.Metrics = nopMetrics{}
+	}
+	return &Cache[K, V]{rdb: rdb, conf: conf, l1: newLocal[V](conf.L1.Size, conf.L1.TTL)}
+}
+
+// Loader 回源查询
+type Loader[V any] func(ctx context.Context) (V, error)
+
+// Get 只读缓存，未命中时返回 ErrMiss 分类的错误，命中空标记时返回 Config.Negative
+func (c *Cache[K, V]) Get(ctx context.Context, k K) (V, error) {
+	v, _, err := c.get(ctx, k, c.conf.Key(k))
+	return v, err
+}
+
+// GetOrLoad 读取缓存，未命中或缓存不可用时回源并写回
+// Loader 的 ctx 不会因发起请求的客户端断开而取消，避免其他等待者一起失败
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, k K, load Loader[V]) (V, error) {
+	key := c.conf.Key(k)
+
+	v, refresh, err := c.get(ctx, k, key)
+	if err == nil {
+		if refresh {
+			c.conf.Metrics.Refresh(c.conf.Name)
+			go c.load(context.WithoutCancel(ctx), k, key, load)
+		}
+		return v, nil
+	}
+	if c.conf.Negative != nil && errors.Is(err, c.conf.Negative) {
+		return v, err
+	}
+
+	// 未命中、Redis 异常和旧格式的缓存都回源
+	return c.load(ctx, k, key, load)
+}
+
+// Set 写入缓存
+func (c *Cache[K, V]) Set(ctx context.Context, k K, v V) error {
+	return c.set(ctx, c.conf.Key(k), c.groups(k, v), v, 0)
+}
+
+// Delete 删除缓存
+func (c *Cache[K, V]) Delete(ctx context.Context, ks ...K) error {
+	if len(ks) == 0 {
+		return nil
+	}
+
+	keys := c.keys(ks)
+	c.l1.del(keys...)
+	if err := c.rdb.Del(ctx, keys...).Err(); err != nil {
+		return c.fail(KindBackend, keys[0], err)
+	}
+	return nil
+}
+
+// Evict 只删除进程内缓存，用于收到其他实例的失效通知时
+func (c *Cache[K, V]) Evict(ks ...K) {
+	c.l1.del(c.keys(ks)...)
+}
+
+// EvictGroups 只删除进程内缓存中属于任一分组的条目，与 InvalidateGroups 配合使用
+func (c *Cache[K, V]) EvictGroups(groups ...string) int {
+	return c.l1.delGroups(groups...)
+}
+
+func (c *Cache[K, V]) keys(ks []K) []string {
+	keys := make([]string, len(ks))
+	for i, k := range ks {
+		keys[i] = c.conf.Key(k)
+	}
+	return keys
+}
+
+func (c *Cache[K, V]) get(ctx context.Context, k K, key string) (V, bool, error) {
+	var v V
+
+	if c.l1 != nil {
+		if it, ok := c.l1.get(key); ok {
+			c.conf.Metrics.L1Hit(c.conf.Name)
+			if it.negative {
+				return v, false, c.conf.Negative
+			}
+			return it.value, false, nil
+		}
+		c.conf.Metrics.L1Miss(c.conf.Name)
+	}
+
+	pipe := c.rdb.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	_, err := pipe.Exec(ctx)
+	if err == redis.Nil {
+		c.conf.Metrics.Miss(c.conf.Name)
+		return v, false, &Error{Kind: KindMiss, Cache: c.conf.Name, Key: key, Err: ErrMiss}
+	}
+	if err != nil {
+		return v, false, c.fail(KindBackend, key, err)
+	}
+
+	e, err := decodeEntry([]byte(get.Val()))
+	if err != nil {
+		return v, false, c.fail(KindCodec, key, err)
+	}
+	c.conf.Metrics.Hit(c.conf.Name)
+
+	if e.negative {
+		c.l1.set(key, v, true, nil, ttl.Val())
+		return v, false, c.conf.Negative
+	}
+	if err := c.conf.Codec.Unmarshal(e.payload, &v); err != nil {
+		return v, false, c.fail(KindCodec, key, err)
+	}
+
+	// 即将提前刷新的值不放入本地，刷新完成后由 set 写入
+	refresh := c.shouldRefresh(e.delta, ttl.Val())
+	if !refresh {
+		c.l1.set(key, v, false, c.groups(k, v), ttl.Val())
+	}
+	return v, refresh, nil
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, k K, key string, load Loader[V]) (V, error) {
+	v, err, _ := c.flight.Do(key, func() (any, error) {
+		ctx := context.WithoutCancel(ctx)
+
+		start := time.Now()
+		v, err := load(ctx)
+		delta := time.Since(start)
+		c.conf.Metrics.Load(c.conf.Name, delta, err)
+
+		if err != nil {
+			if c.conf.Negative != nil && errors.Is(err, c.conf.Negative) {
+				c.setNegative(ctx, key)
+				return v, err
+			}
+			// 回源错误原样返回，调用方仍可直接与业务错误比较
+			c.conf.Metrics.Error(c.conf.Name, KindLoad)
+			return v, err
+		}
+
+		if c.conf.Cacheable == nil || c.conf.Cacheable(v) {
+			c.set(ctx, key, c.groups(k, v), v, delta)
+		}
+		return v, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return v.(V), nil
+}
+
+func (c *Cache[K, V]) set(ctx context.Context, key string, groups []string, v V, delta time.Duration) error {
+	payload, err := c.conf.Codec.Marshal(v)
+	if err != nil {
+		return c.fail(KindCodec, key, err)
+	}
+
+	data := encodeEntry(entry{delta: delta, payload: payload})
+	ttl := c.ttl(key)
+	if len(groups) > 0 {
+		err = setGrouped(ctx, c.rdb, key, data, ttl, groups)
+	} else {
+		err = c.rdb.Set(ctx, key, data, ttl).Err()
+	}
+	if err != nil {
+		// Redis 不可用时也收不到失效通知，不写入本地
+		return c.fail(KindBackend, key, err)
+	}
+	c.l1.set(key, v, false, groups, ttl)
+	return nil
+}
+
+func (c *Cache[K, V]) groups(k K, v V) []string {
+	if c.conf.Groups == nil {
+		return nil
+	}
+	return c.conf.Groups(k, v)
+}
+
+func (c *Cache[K, V]) setNegative(ctx context.Context, key string) {
+	if c.conf.NegativeTTL <= 0 {
+		return
+	}
+	data := encodeEntry(entry{negative: true})
+	if err := c.rdb.Set(ctx, key, data, c.conf.NegativeTTL).Err(); err != nil {
+		c.fail(KindBackend, key, err)
+		return
+	}
+	var zero V
+	c.l1.set(key, zero, true, nil, c.conf.NegativeTTL)
+}
+
+// ttl 按策略计算过期时间并加上随机浮动
+func (c *Cache[K, V]) ttl(key string) time.Duration {
+	ttl := c.conf.TTL(key)
+	if c.conf.Jitter > 0 {
+		ttl += time.Duration((rand.Float64()*2 - 1) * c.conf.Jitter * float64(ttl))
+	}
+	return ttl
+}
+
+// shouldRefresh XFetch：delta × beta × -ln(rand) >= 剩余有效期时提前刷新，
+// 剩余有效期越短、回源越耗时，越可能提前刷新
+func (c *Cache[K, V]) shouldRefresh(delta, ttl time.Duration) bool {
+	if c.conf.Beta <= 0 || delta <= 0 || ttl <= 0 {
+		return false
+	}
+	return float64(delta)*c.conf.Beta*-math.Log(rand.Float64()) >= float64(ttl)
+}
+
+// fail 记录错误并返回分类后的错误，Redis 异常时打印日志
+func (c *Cache[K, V]) fail(kind Kind, key string, err error) error {
+	c.conf.Metrics.Error(c.conf.Name, kind)
+	if kind == KindBackend {
+		log.Printf("缓存访问失败 cache=%s key=%s: %v", c.conf.Name, key, err)
+	}
+	return &Error{Kind: kind, Cache: c.conf.Name, Key: key, Err: err}
+}
+
+// ---------------------------------------
+// 存储格式：版本(1 字节) | 标记(1 字节) | 回源耗时毫秒(uvarint) | 值
+
+const entryVersion byte = 1
+
+const entryNegative byte = 1 << 0
+
+var errEntryFormat = errors.New("unknown cache entry format")
+
+type entry struct {
+	negative bool
+	delta    time.Duration
+	payload  []byte
+}
+
+func encodeEntry(e entry) []byte {
+	var flags byte
+	if e.negative {
+		flags |= entryNegative
+	}
+
+	data := make([]byte, 0, 2+binary.MaxVarintLen64+len(e.payload))
+	data = append(data, entryVersion, flags)
+	data = binary.AppendUvarint(data, uint64(e.delta.Milliseconds()))
+	return append(data, e.payload...)
+}
+
+func decodeEntry(data []byte) (entry, error) {
+	if len(data) < 3 || data[0] != entryVersion {
+		return entry{}, errEntryFormat
+	}
+
+	delta, n := binary.Uvarint(data[2:])
+	if n <= 0 {
+		return entry{}, errEntryFormat
+	}
+	return entry{
+		negative: data[1]&entryNegative != 0,
+		delta:    time.Duration(delta) * time.Millisecond,
+		payload:  data[2+n:],
+	}, nil
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Codec 缓存值的序列化方式
+type Codec interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// ---------------------------------------
+// JSON
+
+type jsonCodec struct{}
+
+// JSON 默认的序列化方式，与接口返回的结构一致，便于在 redis-cli 中查看
+var JSON Codec = jsonCodec{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// ---------------------------------------
+// MessagePack
+
+type msgpackCodec struct {
+	handle *codec.MsgpackHandle
+}
+
+// Msgpack 体积更小、编解码更快，字段名沿用 json tag
+var Msgpack Codec = newMsgpack()
+
+func newMsgpack() msgpackCodec {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.TypeInfos = codec.NewTypeInfos([]string{"codec", "json"})
+	return msgpackCodec{handle: h}
+}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (c msgpackCodec) Marshal(v any) ([]byte, error) {
+	var data []byte
+	err := codec.NewEncoderBytes(&data, c.handle).Encode(v)
+	return data, err
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v any) error {
+	return codec.NewDecoderBytes(data, c.handle).Decode(v)
+}
+
+// ---------------------------------------
+// 压缩
+
+const (
+	flagPlain   byte = 0
+	flagGzipped byte = 1
+)
+
+var errCorrupted = errors.New("corrupted compressed value")
+
+type compressedCodec struct {
+	inner   Codec
+	minSize int
+}
+
+// Compressed 在 inner 的结果超过 minSize 字节时用 gzip 压缩，适合订阅源等较大的值
+func Compressed(inner Codec, minSize int) Codec {
+	return compressedCodec{inner: inner, minSize: minSize}
+}
+
+func (c compressedCodec) Name() string {
+	return "gzip+" + c.inner.Name()
+}
+
+// Marshal 首字节标记是否压缩，较小的值不压缩
+func (c compressedCodec) Marshal(v any) ([]byte, error) {
+	data, err := c.inner.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < c.minSize {
+		return append([]byte{flagPlain}, data...), nil
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(flagGzipped)
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c compressedCodec) Unmarshal(data []byte, v any) error {
+	if len(data) == 0 {
+		return errCorrupted
+	}
+
+	switch data[0] {
+	case flagPlain:
+		return c.inner.Unmarshal(data[1:], v)
+	case flagGzipped:
+		r, err := gzip.NewReader(bytes.NewReader(data[1:]))
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.inner.Unmarshal(raw, v)
+	default:
+		return errCorrupted
+	}
+}
@@ -0,0 +1,163 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return mr, rdb
+}
+
+func testKey(id int) string {
+	return "Test:" + strconv.Itoa(id)
+}
+
+type testValue struct {
+	ID     int            `json:"id"`
+	Title  string         `json:"title"`
+	Tags   []string       `json:"tags"`
+	Counts map[string]int `json:"counts"`
+	At     time.Time      `json:"at"`
+	Nested *testValue     `json:"nested,omitempty"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 18, 8, 30, 0, 123456789, time.UTC)
+	small := testValue{ID: 1, Title: "hello", Tags: []string{"go"}, Counts: map[string]int{"a": 1}, At: at}
+	large := testValue{ID: 2, Title: strings.Repeat("正文", 2000), At: at, Nested: &small}
+
+	codecs := []Codec{JSON, Msgpack, Compressed(JSON, 1024), Compressed(Msgpack, 1024)}
+	values := []struct {
+		name string
+		v    testValue
+	}{
+		{"零值", testValue{}},
+		{"小值", small},
+		{"大值", large},
+	}
+	for _, c := range codecs {
+		for _, tt := range values {
+			t.Run(c.Name()+"/"+tt.name, func(t *testing.T) {
+				data, err := c.Marshal(tt.v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got testValue
+				if err := c.Unmarshal(data, &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.ID != tt.v.ID || got.Title != tt.v.Title || !got.At.Equal(tt.v.At) ||
+					len(got.Tags) != len(tt.v.Tags) || len(got.Counts) != len(tt.v.Counts) ||
+					(got.Nested == nil) != (tt.v.Nested == nil) {
+					t.Errorf("round trip = %+v, want %+v", got, tt.v)
+				}
+			})
+		}
+	}
+}
+
+func TestCompressedCodec(t *testing.T) {
+	c := Compressed(JSON, 100)
+
+	small, _ := c.Marshal("short")
+	if small[0] != flagPlain {
+		t.Errorf("small value flag = %d, want plain", small[0])
+	}
+
+	text := strings.Repeat("a", 1000)
+	large, _ := c.Marshal(text)
+	if large[0] != flagGzipped {
+		t.Errorf("large value flag = %d, want gzipped", large[0])
+	}
+	if len(large) >= len(text) {
+		t.Errorf("compressed size = %d, not smaller than %d", len(large), len(text))
+	}
+
+	corrupted := []struct {
+		name string
+		data []byte
+	}{
+		{"空", nil},
+		{"未知标记", []byte{9, '"', 'a', '"'}},
+		{"损坏的 gzip", append([]byte{flagGzipped}, bytes.Repeat([]byte{0xff}, 8)...)},
+	}
+	for _, tt := range corrupted {
+		t.Run(tt.name, func(t *testing.T) {
+			var s string
+			if err := c.Unmarshal(tt.data, &s); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestEntryFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		e    entry
+	}{
+		{"普通值", entry{delta: 1500 * time.Millisecond, payload: []byte(`{"id":1}`)}},
+		{"空标记", entry{negative: true}},
+		{"无耗时", entry{payload: []byte("x")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeEntry(encodeEntry(tt.e))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.negative != tt.e.negative || got.delta != tt.e.delta || !bytes.Equal(got.payload, tt.e.payload) {
+				t.Errorf("decodeEntry = %+v, want %+v", got, tt.e)
+			}
+		})
+	}
+
+	for _, data := range [][]byte{nil, {entryVersion}, []byte(`{"id":1}`), {entryVersion + 1, 0, 0}} {
+		if _, err := decodeEntry(data); !errors.Is(err, errEntryFormat) {
+			t.Errorf("decodeEntry(%q) err = %v, want errEntryFormat", data, err)
+		}
+	}
+}
+
+func TestCacheCodecErrors(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	c := New(rdb, Config[int, testValue]{
+		Name:  "test",
+		Key:   testKey,
+		TTL:   FixedTTL(time.Minute),
+		Codec: Msgpack,
+	})
+
+	// 旧格式的缓存按格式错误处理，GetOrLoad 回源并覆盖
+	mr.Set("Test:1", `{"id":1}`)
+	if _, err := c.Get(ctx, 1); KindOf(err) != KindCodec {
+		t.Fatalf("Get old format kind = %v, want codec", KindOf(err))
+	}
+	v, err := c.GetOrLoad(ctx, 1, func(context.Context) (testValue, error) {
+		return testValue{ID: 1, Title: "loaded"}, nil
+	})
+	if err != nil || v.Title != "loaded" {
+		t.Fatalf("GetOrLoad = %+v, %v", v, err)
+	}
+	if v, err := c.Get(ctx, 1); err != nil || v.Title != "loaded" {
+		t.Fatalf("Get after load = %+v, %v", v, err)
+	}
+
+	if _, err := c.Get(ctx, 2); !IsMiss(err) {
+		t.Errorf("Get missing kind = %v, want miss", KindOf(err))
+	}
+}
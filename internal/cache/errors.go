@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrMiss 缓存未命中
+var ErrMiss = errors.New("cache miss")
+
+// Kind 缓存错误的分类
+type Kind uint8
+
+const (
+	KindMiss    Kind = iota + 1 // 未命中
+	KindBackend                 // Redis 不可用或超时
+	KindCodec                   // 序列化或反序列化失败，通常是缓存格式变化
+	KindLoad                    // 回源查询失败
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindMiss:
+		return "miss"
+	case KindBackend:
+		return "backend"
+	case KindCodec:
+		return "codec"
+	case KindLoad:
+		return "load"
+	default:
+		return "unknown"
+	}
+}
+
+// Error 带分类的缓存错误
+type Error struct {
+	Kind  Kind
+	Cache string
+	Key   string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("cache %s %s key=%s: %v", e.Cache, e.Kind, e.Key, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// KindOf 返回错误的分类，不是缓存错误时返回 0
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if errors.Is(err, ErrMiss) {
+		return KindMiss
+	}
+	return 0
+}
+
+// IsMiss 是否为未命中
+func IsMiss(err error) bool {
+	return KindOf(err) == KindMiss
+}
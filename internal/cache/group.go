@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// setGroupedScript 写入缓存并加入各分组，分组的过期时间不短于其中最晚过期的缓存
+// 分组为有序集合，分数为成员的过期时间，加入时顺便移除已过期的成员，分组不会随查询条件无限增长；
+// 旧版本的分组为集合，遇到时直接删除
+// KEYS[1] 缓存 key，KEYS[2..] 分组；ARGV[1] 值，ARGV[2] 过期毫秒数，ARGV[3] 当前毫秒时间戳
+var setGroupedScript = redis.NewScript(`
+local ttl = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+for i = 2, #KEYS do
+	if redis.call('TYPE', KEYS[i]).ok == 'set' then
+		redis.call('DEL', KEYS[i])
+	end
+	redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', now)
+	redis.call('ZADD', KEYS[i], now + ttl, KEYS[1])
+	if redis.call('PTTL', KEYS[i]) < ttl then
+		redis.call('PEXPIRE', KEYS[i], ttl)
+	end
+end
+return 1
+`)
+
+// invalidateGroupsScript 原子地删除各分组内的缓存及分组本身，避免删除期间新加入的缓存被遗漏
+// KEYS 为分组
+var invalidateGroupsScript = redis.NewScript(`
+local n = 0
+for _, group in ipairs(KEYS) do
+	local members
+	if redis.call('TYPE', group).ok == 'set' then
+		members = redis.call('SMEMBERS', group)
+	else
+		members = redis.call('ZRANGE', group, 0, -1)
+	end
+	for i = 1, #members, 500 do
+		n = n + redis.call('DEL', unpack(members, i, math.min(i + 499, #members)))
+	end
+	redis.call('DEL', group)
+end
+return n
+`)
+
+func setGrouped(ctx context.Context, rdb *redis.Client, key string, data []byte, ttl time.Duration, groups []string) error {
+	keys := make([]string, 0, len(groups)+1)
+	keys = append(keys, key)
+	for _, g := range groups {
+		keys = append(keys, CacheGroupKey(g))
+	}
+	return setGroupedScript.Run(ctx, rdb, keys, data, ttl.Milliseconds(), time.Now().UnixMilli()).Err()
+}
+
+// InvalidateGroups 删除属于任一分组的缓存，返回删除的缓存数
+func InvalidateGroups(ctx context.Context, rdb *redis.Client, groups ...string) (int64, error) {
+	if len(groups) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]string, len(groups))
+	for i, g := range groups {
+		keys[i] = CacheGroupKey(g)
+	}
+	return invalidateGroupsScript.Run(ctx, rdb, keys).Int64()
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSetGroupedPrunesExpiredMembers(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	group := CacheGroupKey("list")
+
+	if err := setGrouped(ctx, rdb, "Test:short", []byte("a"), time.Second, []string{"list"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := setGrouped(ctx, rdb, "Test:long", []byte("b"), time.Hour, []string{"list"}); err != nil {
+		t.Fatal(err)
+	}
+	if n := rdb.ZCard(ctx, group).Val(); n != 2 {
+		t.Fatalf("members = %d, want 2", n)
+	}
+
+	// Test:short 过期后，下一次写入时从分组中移除
+	time.Sleep(1100 * time.Millisecond)
+	mr.FastForward(1100 * time.Millisecond)
+	if err := setGrouped(ctx, rdb, "Test:other", []byte("c"), time.Hour, []string{"list"}); err != nil {
+		t.Fatal(err)
+	}
+	members := rdb.ZRange(ctx, group, 0, -1).Val()
+	if len(members) != 2 || !slices.Contains(members, "Test:long") || !slices.Contains(members, "Test:other") {
+		t.Errorf("members = %v, want Test:long and Test:other", members)
+	}
+	if ttl := rdb.PTTL(ctx, group).Val(); ttl <= 59*time.Minute {
+		t.Errorf("group ttl = %v, want about 1h", ttl)
+	}
+}
+
+func TestGroupsLegacySet(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	group := CacheGroupKey("article:1")
+
+	// 旧版本以集合保存的分组仍可失效
+	rdb.Set(ctx, "Test:old", "x", time.Hour)
+	rdb.SAdd(ctx, group, "Test:old")
+	if n, err := InvalidateGroups(ctx, rdb, "article:1"); err != nil || n != 1 {
+		t.Fatalf("InvalidateGroups = %d, %v, want 1", n, err)
+	}
+
+	// 写入时遇到旧格式的分组直接替换
+	rdb.SAdd(ctx, group, "Test:old")
+	if err := setGrouped(ctx, rdb, "Test:new", []byte("y"), time.Hour, []string{"article:1"}); err != nil {
+		t.Fatal(err)
+	}
+	if members := rdb.ZRange(ctx, group, 0, -1).Val(); len(members) != 1 || members[0] != "Test:new" {
+		t.Errorf("members = %v, want [Test:new]", members)
+	}
+}
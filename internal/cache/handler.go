@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"my_web/backend/internal/httpserver"
+	"my_web/backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	httpserver.BaseHandler
+	stats *Stats
+}
+
+func NewHandler(stats *Stats) *Handler {
+	return &Handler{
+		stats: stats,
+	}
+}
+
+func (h *Handler) RegisterRoutes(e *gin.Engine) {
+	admin := e.Group("/api/admin/cache", middleware.JWTAuth(), middleware.RequirePermission(middleware.PermAnalyticsRead))
+	{
+		admin.GET("/stats", h.getStats)
+	}
+}
+
+// 各缓存的命中率、回源次数和错误数，进程重启后清零
+func (h *Handler) getStats(ctx *gin.Context) {
+	h.Success(ctx, h.stats.Snapshot())
+}
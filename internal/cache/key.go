@@ -0,0 +1,10 @@
+package cache
+
+import (
+	"fmt"
+)
+
+// CacheGroupKey 缓存分组，记录属于该分组的缓存 key
+func CacheGroupKey(group string) string {
+	return fmt.Sprintf("Cache:Group:%s", group)
+}
@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"container/list"
+	"slices"
+	"sync"
+	"time"
+)
+
+// local 进程内的 LRU 缓存，按条目数和过期时间限制，nil 表示不启用
+// 存放的是反序列化后的值，命中时各调用方拿到的是同一个值
+type local[V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List // 最近使用的在前
+}
+
+type localItem[V any] struct {
+	key      string
+	value    V
+	negative bool
+	groups   []string
+	expires  time.Time
+}
+
+func newLocal[V any](size int, ttl time.Duration) *local[V] {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &local[V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+// get 返回未过期的条目，过期的顺便删除
+func (l *local[V]) get(key string) (*localItem[V], bool) {
+	if l == nil {
+		return nil, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	it := el.Value.(*localItem[V])
+	if time.Now().After(it.expires) {
+		l.remove(el)
+		return nil, false
+	}
+	l.order.MoveToFront(el)
+	return it, true
+}
+
+// set 写入条目，ttl 为 Redis 中的剩余有效期，本地不会比 Redis 晚过期
+func (l *local[V]) set(key string, v V, negative bool, groups []string, ttl time.Duration) {
+	if l == nil || ttl <= 0 {
+		return
+	}
+	ttl = min(ttl, l.ttl)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	it := &localItem[V]{key: key, value: v, negative: negative, groups: groups, expires: time.Now().Add(ttl)}
+	if el, ok := l.items[key]; ok {
+		el.Value = it
+		l.order.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(it)
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *local[V]) del(keys ...string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.remove(el)
+		}
+	}
+}
+
+// delGroups 删除属于任一分组的条目，返回删除数；条目数有上限，直接遍历
+func (l *local[V]) delGroups(groups ...string) int {
+	if l == nil || len(groups) == 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for el := l.order.Front(); el != nil; {
+		next := el.Next()
+		it := el.Value.(*localItem[V])
+		if slices.ContainsFunc(it.groups, func(g string) bool { return slices.Contains(groups, g) }) {
+			l.remove(el)
+			n++
+		}
+		el = next
+	}
+	return n
+}
+
+func (l *local[V]) remove(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.items, el.Value.(*localItem[V]).key)
+}
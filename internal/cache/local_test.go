@@ -0,0 +1,224 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLocalDisabled(t *testing.T) {
+	for _, l := range []*local[int]{newLocal[int](0, time.Minute), newLocal[int](10, 0)} {
+		if l != nil {
+			t.Fatal("newLocal should return nil when size or ttl is 0")
+		}
+		// nil 上的操作都是空操作
+		l.set("a", 1, false, nil, time.Minute)
+		if _, ok := l.get("a"); ok {
+			t.Error("disabled local returned a value")
+		}
+		l.del("a")
+		if n := l.delGroups("g"); n != 0 {
+			t.Errorf("delGroups = %d, want 0", n)
+		}
+	}
+}
+
+func TestLocalLRU(t *testing.T) {
+	l := newLocal[int](2, time.Minute)
+	l.set("a", 1, false, nil, time.Minute)
+	l.set("b", 2, false, nil, time.Minute)
+	l.get("a") // a 变为最近使用
+	l.set("c", 3, false, nil, time.Minute)
+
+	if _, ok := l.get("b"); ok {
+		t.Error("least recently used entry b not evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if it, ok := l.get(key); !ok || it.value != want {
+			t.Errorf("get(%s) = %v, %v, want %d", key, it, ok, want)
+		}
+	}
+
+	// 覆盖已有条目不增加条目数
+	l.set("a", 10, false, nil, time.Minute)
+	if it, _ := l.get("a"); it.value != 10 || l.order.Len() != 2 {
+		t.Errorf("overwrite: value = %d, len = %d", it.value, l.order.Len())
+	}
+}
+
+func TestLocalTTL(t *testing.T) {
+	l := newLocal[int](10, time.Minute)
+
+	// 不超过 Redis 中的剩余有效期
+	l.set("short", 1, false, nil, 20*time.Millisecond)
+	// 不超过本地配置的有效期
+	l.set("long", 2, false, nil, time.Hour)
+	if it, _ := l.get("long"); time.Until(it.expires) > time.Minute {
+		t.Errorf("expires in %v, want <= 1m", time.Until(it.expires))
+	}
+	// 已过期的不写入
+	l.set("expired", 3, false, nil, 0)
+	if _, ok := l.get("expired"); ok {
+		t.Error("entry with ttl 0 stored")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, ok := l.get("short"); ok {
+		t.Error("expired entry returned")
+	}
+	if _, ok := l.items["short"]; ok {
+		t.Error("expired entry not removed")
+	}
+}
+
+func TestLocalDelGroups(t *testing.T) {
+	l := newLocal[int](10, time.Minute)
+	l.set("a", 1, false, []string{"article:1", "list"}, time.Minute)
+	l.set("b", 2, false, []string{"article:2", "list"}, time.Minute)
+	l.set("c", 3, false, []string{"article:2"}, time.Minute)
+	l.set("d", 4, false, nil, time.Minute)
+
+	tests := []struct {
+		name   string
+		groups []string
+		n      int
+		left   []string
+	}{
+		{"无分组", nil, 0, []string{"a", "b", "c", "d"}},
+		{"不存在的分组", []string{"tag:go"}, 0, []string{"a", "b", "c", "d"}},
+		{"单篇文章", []string{"article:1"}, 1, []string{"b", "c", "d"}},
+		{"多个分组", []string{"list", "article:2"}, 2, []string{"d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if n := l.delGroups(tt.groups...); n != tt.n {
+				t.Errorf("delGroups = %d, want %d", n, tt.n)
+			}
+			if len(l.items) != len(tt.left) {
+				t.Errorf("left %d entries, want %v", len(l.items), tt.left)
+			}
+			for _, key := range tt.left {
+				if _, ok := l.get(key); !ok {
+					t.Errorf("entry %s removed", key)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheL1Invalidation(t *testing.T) {
+	ctx := context.Background()
+	mr, rdb := newTestRedis(t)
+	c := New(rdb, Config[int, testValue]{
+		Name:   "test",
+		Key:    testKey,
+		TTL:    FixedTTL(time.Minute),
+		Groups: func(id int, _ testValue) []string { return []string{"article:" + strconv.Itoa(id), "list"} },
+		L1:     L1Config{Size: 10, TTL: time.Minute},
+	})
+
+	loads := 0
+	load := func(id int, title string) Loader[testValue] {
+		return func(context.Context) (testValue, error) {
+			loads++
+			return testValue{ID: id, Title: title}, nil
+		}
+	}
+	get := func(id int) string {
+		t.Helper()
+		v, err := c.GetOrLoad(ctx, id, load(id, "db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.Title
+	}
+
+	if err := c.Set(ctx, 1, testValue{ID: 1, Title: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, 2, testValue{ID: 2, Title: "v1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟其他实例修改了 Redis：本地缓存在失效通知前仍返回旧值
+	mr.Del(testKey(1))
+	mr.Del(testKey(2))
+	if got := get(1); got != "v1" {
+		t.Fatalf("L1 hit = %q, want v1", got)
+	}
+
+	// Evict 只删除本地，之后从 Redis 读取，Redis 中已没有则回源
+	c.Evict(1)
+	if got := get(1); got != "db" || loads != 1 {
+		t.Errorf("after Evict = %q (loads %d), want db (1)", got, loads)
+	}
+	if got := get(2); got != "v1" {
+		t.Errorf("other key after Evict = %q, want v1", got)
+	}
+
+	// EvictGroups 删除本地分组内的条目
+	if n := c.EvictGroups("list"); n != 2 {
+		t.Errorf("EvictGroups = %d, want 2", n)
+	}
+	if got := get(2); got != "db" || loads != 2 {
+		t.Errorf("after EvictGroups = %q (loads %d), want db (2)", got, loads)
+	}
+
+	// InvalidateGroups 删除 Redis 中的分组，配合 EvictGroups 清除本地
+	if n, err := InvalidateGroups(ctx, rdb, "article:1"); err != nil || n != 1 {
+		t.Errorf("InvalidateGroups = %d, %v, want 1", n, err)
+	}
+	c.EvictGroups("article:1")
+	if mr.Exists(testKey(1)) {
+		t.Error("grouped key not deleted from Redis")
+	}
+	if get(1); loads != 3 {
+		t.Errorf("loads = %d, want 3", loads)
+	}
+
+	// Delete 同时删除本地和 Redis
+	if err := c.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, 2); !IsMiss(err) {
+		t.Errorf("Get after Delete kind = %v, want miss", KindOf(err))
+	}
+}
+
+func TestCacheL1Negative(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	errNotFound := errors.New("not found")
+	c := New(rdb, Config[int, testValue]{
+		Name:        "test",
+		Key:         testKey,
+		TTL:         FixedTTL(time.Minute),
+		Negative:    errNotFound,
+		NegativeTTL: time.Minute,
+		L1:          L1Config{Size: 10, TTL: time.Minute},
+	})
+
+	loads := 0
+	load := func(context.Context) (testValue, error) {
+		loads++
+		return testValue{}, errNotFound
+	}
+	for range 3 {
+		if _, err := c.GetOrLoad(ctx, 1, load); err != errNotFound {
+			t.Fatalf("err = %v, want errNotFound", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+
+	// 本地的空标记同样可以被 Evict 清除
+	c.Evict(1)
+	rdb.Del(ctx, testKey(1))
+	c.GetOrLoad(ctx, 1, load)
+	if loads != 2 {
+		t.Errorf("loads after Evict = %d, want 2", loads)
+	}
+}
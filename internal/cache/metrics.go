@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics 缓存事件的回调，cache 为缓存名称，实现需要并发安全
+type Metrics interface {
+	Hit(cache string)
+	Miss(cache string)
+	Refresh(cache string) // 提前刷新
+	// Error 缓存访问失败，回源失败记为 KindLoad（返回 Negative 错误的不算失败）
+	Error(cache string, kind Kind)
+	Load(cache string, d time.Duration, err error)
+	L1Hit(cache string) // 进程内缓存命中，未命中时才会计入 Hit 或 Miss
+	L1Miss(cache string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) Hit(string)                        {}
+func (nopMetrics) Miss(string)                       {}
+func (nopMetrics) Refresh(string)                    {}
+func (nopMetrics) Error(string, Kind)                {}
+func (nopMetrics) Load(string, time.Duration, error) {}
+func (nopMetrics) L1Hit(string)                      {}
+func (nopMetrics) L1Miss(string)                     {}
+
+// Stats 按缓存名称累计各类事件的 Metrics 实现
+type Stats struct {
+	caches sync.Map // name -> *counters
+}
+
+type counters struct {
+	hits, misses, refreshes   atomic.Int64
+	loads, loadErrors, loadMs atomic.Int64
+	backend, codec            atomic.Int64
+	l1Hits, l1Misses          atomic.Int64
+}
+
+// CacheStats 某个缓存的统计
+type CacheStats struct {
+	Hits       int64   `json:"hits"`
+	Misses     int64   `json:"misses"`
+	HitRate    float64 `json:"hitRate"`
+	Refreshes  int64   `json:"refreshes"`
+	Loads      int64   `json:"loads"`
+	LoadErrors int64   `json:"loadErrors"` // 回源失败，不含返回 Negative 错误的
+	AvgLoadMs  float64 `json:"avgLoadMs"`
+	Errors     int64   `json:"errors"` // Redis 和序列化错误
+
+	// 进程内缓存，只统计当前实例
+	L1Hits    int64   `json:"l1Hits"`
+	L1Misses  int64   `json:"l1Misses"`
+	L1HitRate float64 `json:"l1HitRate"`
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+func (s *Stats) get(name string) *counters {
+	if c, ok := s.caches.Load(name); ok {
+		return c.(*counters)
+	}
+	c, _ := s.caches.LoadOrStore(name, &counters{})
+	return c.(*counters)
+}
+
+func (s *Stats) Hit(cache string) {
+	s.get(cache).hits.Add(1)
+}
+
+func (s *Stats) Miss(cache string) {
+	s.get(cache).misses.Add(1)
+}
+
+func (s *Stats) Refresh(cache string) {
+	s.get(cache).refreshes.Add(1)
+}
+
+func (s *Stats) Error(cache string, kind Kind) {
+	c := s.get(cache)
+	switch kind {
+	case KindBackend:
+		c.backend.Add(1)
+	case KindCodec:
+		c.codec.Add(1)
+	case KindLoad:
+		c.loadErrors.Add(1)
+	}
+}
+
+func (s *Stats) Load(cache string, d time.Duration, err error) {
+	c := s.get(cache)
+	c.loads.Add(1)
+	c.loadMs.Add(d.Milliseconds())
+}
+
+func (s *Stats) L1Hit(cache string) {
+	s.get(cache).l1Hits.Add(1)
+}
+
+func (s *Stats) L1Miss(cache string) {
+	s.get(cache).l1Misses.Add(1)
+}
+
+// Snapshot 当前各缓存的统计
+func (s *Stats) Snapshot() map[string]CacheStats {
+	snapshot := map[string]CacheStats{}
+	s.caches.Range(func(k, v any) bool {
+		c := v.(*counters)
+		st := CacheStats{
+			Hits:       c.hits.Load(),
+			Misses:     c.misses.Load(),
+			Refreshes:  c.refreshes.Load(),
+			Loads:      c.loads.Load(),
+			LoadErrors: c.loadErrors.Load(),
+			Errors:     c.backend.Load() + c.codec.Load(),
+			L1Hits:     c.l1Hits.Load(),
+			L1Misses:   c.l1Misses.Load(),
+		}
+		if total := st.Hits + st.Misses; total > 0 {
+			st.HitRate = float64(st.Hits) / float64(total)
+		}
+		if total := st.L1Hits + st.L1Misses; total > 0 {
+			st.L1HitRate = float64(st.L1Hits) / float64(total)
+		}
+		if st.Loads > 0 {
+			st.AvgLoadMs = float64(c.loadMs.Load()) / float64(st.Loads)
+		}
+		snapshot[k.(string)] = st
+		return true
+	})
+	return snapshot
+}
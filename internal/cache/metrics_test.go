@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStatsLoadErrors(t *testing.T) {
+	ctx := context.Background()
+	_, rdb := newTestRedis(t)
+	errNotFound := errors.New("not found")
+	errDB := errors.New("db down")
+	stats := NewStats()
+	c := New(rdb, Config[int, testValue]{
+		Name:        "test",
+		Key:         testKey,
+		TTL:         FixedTTL(time.Minute),
+		Negative:    errNotFound,
+		NegativeTTL: time.Minute,
+		Metrics:     stats,
+	})
+
+	// 回源失败原样返回，计入 LoadErrors
+	_, err := c.GetOrLoad(ctx, 1, func(context.Context) (testValue, error) {
+		return testValue{}, errDB
+	})
+	if err != errDB {
+		t.Fatalf("err = %v, want errDB", err)
+	}
+	// 不存在的记录不算回源失败
+	_, err = c.GetOrLoad(ctx, 2, func(context.Context) (testValue, error) {
+		return testValue{}, errNotFound
+	})
+	if err != errNotFound {
+		t.Fatalf("err = %v, want errNotFound", err)
+	}
+	_, err = c.GetOrLoad(ctx, 3, func(context.Context) (testValue, error) {
+		return testValue{ID: 3}, nil
+	})
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+
+	st := stats.Snapshot()["test"]
+	if st.Loads != 3 || st.LoadErrors != 1 || st.Errors != 0 {
+		t.Errorf("stats = %+v, want 3 loads, 1 load error, 0 errors", st)
+	}
+}
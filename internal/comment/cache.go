@@ -0,0 +1,79 @@
+package comment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrCacheMiss = errors.New("cache miss")
+)
+
+const (
+	commentCacheExpiration = 60 * time.Minute
+)
+
+func cacheGetComments(ctx context.Context, rdb *redis.Client, articleID, page, pageSize int) ([]*CommentNode, int, error) {
+	data, err := rdb.Get(ctx, CommentByArticleKey(articleID, page, pageSize)).Result()
+	if err == redis.Nil {
+		return nil, 0, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("缓存获取异常 %w", err)
+	}
+
+	var comments []*CommentNode
+	if err = json.Unmarshal([]byte(data), &comments); err != nil {
+		return nil, 0, fmt.Errorf("反序列化失败 %w", err)
+	}
+
+	totalData, err := rdb.Get(ctx, CommentByArticleTotalKey(articleID)).Result()
+	if err == redis.Nil {
+		return comments, 0, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取总数失败 %w", err)
+	}
+
+	total, err := strconv.Atoi(totalData)
+	if err != nil {
+		return nil, 0, fmt.Errorf("总数解析失败 %w", err)
+	}
+
+	return comments, total, nil
+}
+
+func cacheSetComments(ctx context.Context, rdb *redis.Client, articleID, page, pageSize int, comments []*CommentNode, total int) error {
+	data, err := json.Marshal(comments)
+	if err != nil {
+		return fmt.Errorf("序列化失败 %w", err)
+	}
+
+	pipe := rdb.Pipeline()
+	pipe.Set(ctx, CommentByArticleKey(articleID, page, pageSize), data, commentCacheExpiration)
+	pipe.Set(ctx, CommentByArticleTotalKey(articleID), strconv.Itoa(total), commentCacheExpiration)
+
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// cacheDelComments 删除文章的全部评论分页缓存
+func cacheDelComments(ctx context.Context, rdb *redis.Client, articleID int) error {
+	keys := []string{CommentByArticleTotalKey(articleID)}
+
+	iter := rdb.Scan(ctx, 0, CommentByArticleKeyPattern(articleID), 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return rdb.Del(ctx, keys...).Err()
+}
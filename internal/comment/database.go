@@ -0,0 +1,155 @@
+package comment
+
+import (
+	"gorm.io/gorm"
+)
+
+// repoGetRootComments 分页获取文章已通过的顶层评论，新评论在前
+func repoGetRootComments(db *gorm.DB, articleID, page, pageSize int) ([]Comment, int, error) {
+	var comments []Comment
+	var total int64
+
+	query := func() *gorm.DB {
+		return db.
+			Model(&Comment{}).
+			Where("article_id = ? AND parent_id = 0 AND status = ?", articleID, CommentApproved)
+	}
+
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query().
+		Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&comments)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return comments, int(total), nil
+}
+
+// repoGetReplies 获取顶层评论下已通过的全部回复，按时间升序
+func repoGetReplies(db *gorm.DB, rootIDs []int) ([]Comment, error) {
+	comments := []Comment{}
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+
+	result := db.
+		Where("root_id IN ? AND status = ?", rootIDs, CommentApproved).
+		Order("created_at, id").
+		Find(&comments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return comments, nil
+}
+
+func repoGetComment(db *gorm.DB, id int) (*Comment, error) {
+	var comment Comment
+
+	result := db.First(&comment, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &comment, nil
+}
+
+func repoCreateComment(db *gorm.DB, comment *Comment) error {
+	return db.Create(comment).Error
+}
+
+// repoGetCommentsByStatus 审核队列，按时间升序
+func repoGetCommentsByStatus(db *gorm.DB, status CommentStatus, page, pageSize int) ([]Comment, int, error) {
+	var comments []Comment
+	var total int64
+
+	query := func() *gorm.DB {
+		return db.
+			Model(&Comment{}).
+			Where("status = ?", status)
+	}
+
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	result := query().
+		Order("created_at, id").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&comments)
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	return comments, int(total), nil
+}
+
+func repoGetComments(db *gorm.DB, ids []int) ([]Comment, error) {
+	comments := []Comment{}
+
+	result := db.Where("id IN ?", ids).Find(&comments)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return comments, nil
+}
+
+// repoUpdateStatus 批量修改评论状态并写入判定记录
+func repoUpdateStatus(db *gorm.DB, ids []int, status CommentStatus, verdicts []CommentVerdict) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Model(&Comment{}).
+			Where("id IN ?", ids).
+			Update("status", status)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if len(verdicts) == 0 {
+			return nil
+		}
+		return tx.Create(&verdicts).Error
+	})
+}
+
+func repoCreateVerdict(db *gorm.DB, verdict *CommentVerdict) error {
+	return db.Create(verdict).Error
+}
+
+// repoGetVerdicts 评论的全部判定记录，按时间升序
+func repoGetVerdicts(db *gorm.DB, commentID int) ([]CommentVerdict, error) {
+	verdicts := []CommentVerdict{}
+
+	result := db.
+		Where("comment_id = ?", commentID).
+		Order("created_at, id").
+		Find(&verdicts)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return verdicts, nil
+}
+
+// repoCountApproved 文章已通过的评论数
+func repoCountApproved(db *gorm.DB, articleID int) (int, error) {
+	var count int64
+
+	result := db.
+		Model(&Comment{}).
+		Where("article_id = ? AND status = ?", articleID, CommentApproved).
+		Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return int(count), nil
+}
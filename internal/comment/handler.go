@@ -0,0 +1,229 @@
+package comment
+
+import (
+	"errors"
+	"my_web/backend/internal/article"
+	"my_web/backend/internal/httpserver"
+	"my_web/backend/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	httpserver.BaseHandler
+	service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/api/article/:id/comments", middleware.OptionalJWTAuth())
+	{
+		r.GET("", h.getComments)
+		r.POST("", h.createComment)
+	}
+	e.GET("/api/comment/formToken", h.getFormToken)
+
+	admin := e.Group("/api/comment", middleware.JWTAuth(), middleware.RequirePermission(middleware.PermCommentModerate))
+	{
+		admin.GET("", h.getModerationQueue)
+		admin.POST("/moderate", h.moderateComments)
+		admin.DELETE("/:id", h.deleteComment)
+		admin.GET("/:id/verdicts", h.getVerdicts)
+	}
+}
+
+// 获取文章评论
+func (h *Handler) getComments(ctx *gin.Context) {
+	articleID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "10"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	comments, total, err := h.service.GetComments(ctx.Request.Context(), articleID, page, pageSize)
+	if err != nil {
+		h.failComment(ctx, err)
+		return
+	}
+
+	h.Success(ctx, httpserver.PageResult[*CommentNode]{
+		Page:  page,
+		Size:  pageSize,
+		Total: total,
+		Data:  comments,
+	})
+}
+
+// 发表评论
+func (h *Handler) createComment(ctx *gin.Context) {
+	articleID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	var req CommentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	comment, err := h.service.CreateComment(ctx.Request.Context(), articleID, &req, commenter(ctx))
+	if err != nil {
+		h.failComment(ctx, err)
+		return
+	}
+
+	h.Success(ctx, comment)
+}
+
+// 审核队列，status 默认为待审核
+func (h *Handler) getModerationQueue(ctx *gin.Context) {
+	status, err := strconv.Atoi(ctx.DefaultQuery("status", "0"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	comments, total, err := h.service.GetCommentsByStatus(ctx.Request.Context(), CommentStatus(status), page, pageSize)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, httpserver.PageResult[ModerationComment]{
+		Page:  page,
+		Size:  pageSize,
+		Total: total,
+		Data:  comments,
+	})
+}
+
+// 批量审核
+func (h *Handler) moderateComments(ctx *gin.Context) {
+	var req ModerateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	if err := h.service.ModerateComments(ctx.Request.Context(), req.IDs, req.Status, moderatorID(ctx)); err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, nil)
+}
+
+// 删除评论
+func (h *Handler) deleteComment(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	if err := h.service.DeleteComment(ctx.Request.Context(), id, moderatorID(ctx)); err != nil {
+		h.failComment(ctx, err)
+		return
+	}
+
+	h.Success(ctx, nil)
+}
+
+// 获取评论表单 token，?articleId= 为要评论的文章
+func (h *Handler) getFormToken(ctx *gin.Context) {
+	articleID, err := strconv.Atoi(ctx.Query("articleId"))
+	if err != nil || articleID <= 0 {
+		h.Fail(ctx, httpserver.ErrRequest, errors.New("invalid articleId"))
+		return
+	}
+
+	h.Success(ctx, gin.H{"formToken": h.service.FormToken(articleID)})
+}
+
+// 评论的判定记录
+func (h *Handler) getVerdicts(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	verdicts, err := h.service.GetVerdicts(ctx.Request.Context(), id)
+	if err != nil {
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+		return
+	}
+
+	h.Success(ctx, verdicts)
+}
+
+func moderatorID(ctx *gin.Context) int {
+	if claims, ok := middleware.GetClaims(ctx); ok {
+		return claims.UserID()
+	}
+	return 0
+}
+
+func commenter(ctx *gin.Context) *Commenter {
+	c := &Commenter{
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+	}
+	if claims, ok := middleware.GetClaims(ctx); ok {
+		c.UserID = claims.UserID()
+		c.Name = claims.Name
+		c.IsAdmin = claims.HasRole(middleware.RoleAdmin)
+	}
+	return c
+}
+
+// failComment 将评论相关错误映射为响应
+func (h *Handler) failComment(ctx *gin.Context, err error) {
+	switch err {
+	case article.ErrArticleNotFound:
+		h.FailStatus(ctx, http.StatusNotFound, httpserver.ErrArticleNotFound, nil)
+	case ErrCommentNotFound:
+		h.FailStatus(ctx, http.StatusNotFound, httpserver.ErrCommentNotFound, nil)
+	case ErrTooDeep:
+		h.Fail(ctx, httpserver.ErrCommentDepth, nil)
+	case ErrAuthorRequired:
+		h.Fail(ctx, httpserver.ErrCommentAuthor, nil)
+	case ErrInvalidWebsite:
+		h.Fail(ctx, httpserver.ErrCommentWebsite, nil)
+	default:
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+	}
+}
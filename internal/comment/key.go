@@ -0,0 +1,17 @@
+package comment
+
+import (
+	"fmt"
+)
+
+func CommentByArticleKey(articleID, page, pageSize int) string {
+	return fmt.Sprintf("Comment:ByArticle:%d:%d:%d", articleID, page, pageSize)
+}
+
+func CommentByArticleTotalKey(articleID int) string {
+	return fmt.Sprintf("Comment:ByArticleTotal:%d", articleID)
+}
+
+func CommentByArticleKeyPattern(articleID int) string {
+	return fmt.Sprintf("Comment:ByArticle:%d:*", articleID)
+}
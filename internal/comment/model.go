@@ -0,0 +1,175 @@
+package comment
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"my_web/backend/internal/spam"
+	"time"
+)
+
+type CommentStatus int
+
+const (
+	CommentPending  = iota // 待审核
+	CommentApproved        // 已通过
+	CommentSpam            // 垃圾评论
+	CommentDeleted         // 已删除
+)
+
+type Comment struct {
+	ID         int           `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	ArticleID  int           `json:"articleId" gorm:"index:idx_comments_article_status"`
+	ParentID   int           `json:"parentId" gorm:"index"` // 回复的评论，0 表示顶层评论
+	RootID     int           `json:"rootId" gorm:"index"`   // 所属顶层评论，顶层评论为 0
+	Depth      int           `json:"depth"`                 // 嵌套层级，顶层为 0
+	UserID     int           `json:"userId"`                // 评论用户，匿名为 0
+	AuthorName string        `json:"authorName" gorm:"size:64"`
+	Email      string        `json:"-" gorm:"size:128"`
+	Website    string        `json:"website" gorm:"size:256"`
+	Content    string        `json:"content" gorm:"type:text"`
+	Status     CommentStatus `json:"status" gorm:"index:idx_comments_article_status"`
+	IP         string        `json:"-" gorm:"size:64"`
+	UserAgent  string        `json:"-"`
+}
+
+const (
+	VerdictFilter    = "filter"    // 垃圾检测
+	VerdictModerator = "moderator" // 人工审核
+)
+
+// CommentVerdict 评论的判定记录，垃圾检测和人工审核各记一条，用于审计
+type CommentVerdict struct {
+	ID          int           `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt   time.Time     `json:"created_at"`
+	CommentID   int           `json:"commentId" gorm:"index"`
+	Source      string        `json:"source" gorm:"size:16"`
+	Score       float64       `json:"score"`                    // 垃圾检测得分，人工审核时为 0
+	Status      CommentStatus `json:"status"`                   // 判定后的评论状态
+	Details     Verdicts      `json:"details" gorm:"type:text"` // 各检测器的结论
+	ModeratorID int           `json:"moderatorId"`              // 审核员，垃圾检测时为 0
+}
+
+// Verdicts 检测器结论，以 JSON 存储
+type Verdicts []spam.Verdict
+
+func (v Verdicts) Value() (driver.Value, error) {
+	if v == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+func (v *Verdicts) Scan(src any) error {
+	var data []byte
+	switch s := src.(type) {
+	case nil:
+		*v = nil
+		return nil
+	case string:
+		data = []byte(s)
+	case []byte:
+		data = s
+	default:
+		return fmt.Errorf("无法解析判定记录类型 %T", src)
+	}
+	if len(data) == 0 {
+		*v = nil
+		return nil
+	}
+	return json.Unmarshal(data, v)
+}
+
+// trainLabel 审核状态对应的训练标签，只有通过和垃圾参与训练
+func trainLabel(status CommentStatus) (isSpam bool, ok bool) {
+	switch status {
+	case CommentApproved:
+		return false, true
+	case CommentSpam:
+		return true, true
+	}
+	return false, false
+}
+
+// CommentNode 评论树节点
+type CommentNode struct {
+	Comment
+	Replies []*CommentNode `json:"replies"`
+}
+
+// buildTree 将顶层评论和其下的全部回复组装为评论树
+// 父评论未通过审核的回复不会出现在树中
+func buildTree(roots, replies []Comment) []*CommentNode {
+	nodes := make(map[int]*CommentNode, len(roots)+len(replies))
+	tree := make([]*CommentNode, 0, len(roots))
+	for _, c := range roots {
+		node := &CommentNode{Comment: c, Replies: []*CommentNode{}}
+		nodes[c.ID] = node
+		tree = append(tree, node)
+	}
+
+	// replies 按创建时间升序，父评论总在子评论之前
+	for _, c := range replies {
+		parent, ok := nodes[c.ParentID]
+		if !ok {
+			continue
+		}
+		node := &CommentNode{Comment: c, Replies: []*CommentNode{}}
+		nodes[c.ID] = node
+		parent.Replies = append(parent.Replies, node)
+	}
+
+	return tree
+}
+
+// ModerationComment 审核队列中的评论，包含前台不展示的字段
+type ModerationComment struct {
+	Comment
+	Email     string `json:"email"`
+	IP        string `json:"ip"`
+	UserAgent string `json:"userAgent"`
+}
+
+func toModeration(comments []Comment) []ModerationComment {
+	list := make([]ModerationComment, len(comments))
+	for i, c := range comments {
+		list[i] = ModerationComment{
+			Comment:   c,
+			Email:     c.Email,
+			IP:        c.IP,
+			UserAgent: c.UserAgent,
+		}
+	}
+	return list
+}
+
+// Commenter 发表评论的人
+type Commenter struct {
+	UserID    int    // 登录用户ID，匿名为 0
+	Name      string // 登录用户名
+	IsAdmin   bool
+	IP        string
+	UserAgent string
+}
+
+// ---------------------------------------
+
+type CommentRequest struct {
+	ParentID   int    `json:"parentId" binding:"min=0"`
+	AuthorName string `json:"authorName" binding:"max=64"` // 匿名评论必填
+	Email      string `json:"email" binding:"omitempty,email,max=128"`
+	Website    string `json:"website" binding:"omitempty,http_url,max=256"`
+	Content    string `json:"content" binding:"required,max=2000"`
+
+	Honeypot  string `json:"hp"`        // 蜜罐字段，前端隐藏，正常用户不会填写
+	FormToken string `json:"formToken"` // 渲染表单时从 /api/comment/formToken 获取
+}
+
+// ModerateRequest 批量审核
+type ModerateRequest struct {
+	IDs    []int         `json:"ids" binding:"required,min=1,max=100,dive,min=1"`
+	Status CommentStatus `json:"status" binding:"oneof=0 1 2 3"`
+}
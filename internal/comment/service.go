@@ -0,0 +1,379 @@
+package comment
+
+import (
+	"context"
+	"errors"
+	"log"
+	"my_web/backend/internal/article"
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/event"
+	"my_web/backend/internal/spam"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCommentNotFound = errors.New("comment not found")
+	ErrTooDeep         = errors.New("comment nested too deep")
+	ErrAuthorRequired  = errors.New("author name required")
+	ErrInvalidWebsite  = errors.New("website must be an http or https url")
+)
+
+const (
+	defaultMaxDepth        = 3
+	defaultMaxLinks        = 2
+	defaultMinSubmitTime   = 3 * time.Second
+	defaultSpamThreshold   = 0.9
+	defaultReviewThreshold = 0.5
+)
+
+type Service struct {
+	DB  *gorm.DB
+	RDB *redis.Client
+
+	articles *article.Service
+	bus      *event.Bus
+	conf     *config.CommentConfig
+
+	filter     *spam.Filter
+	bayes      *spam.Bayes
+	submitTime *spam.MinSubmitTime
+}
+
+func NewCommentService(db *gorm.DB, rdb *redis.Client, articles *article.Service, bus *event.Bus, conf *config.CommentConfig) *Service {
+	s := &Service{
+		DB:       db,
+		RDB:      rdb,
+		articles: articles,
+		bus:      bus,
+		conf:     conf,
+	}
+	bus.Subscribe(s.onArticleEvent, event.ArticleUpdated, event.ArticleDeleted)
+
+	maxLinks := conf.Spam.MaxLinks
+	if maxLinks <= 0 {
+		maxLinks = defaultMaxLinks
+	}
+	minSubmitTime := conf.Spam.MinSubmitTime
+	if minSubmitTime <= 0 {
+		minSubmitTime = defaultMinSubmitTime
+	}
+
+	s.bayes = spam.NewBayes(rdb)
+	s.submitTime = spam.NewMinSubmitTime(rdb, conf.Spam.FormSecret, minSubmitTime)
+	s.filter = spam.NewFilter(
+		spam.NewBlocklist(conf.Spam.Blocklist),
+		spam.NewLinkCount(maxLinks),
+		spam.Honeypot{},
+		s.submitTime,
+		s.bayes,
+	)
+
+	return s
+}
+
+// 签发某篇文章的评论表单 token，用于检测填写耗时
+func (s *Service) FormToken(articleID int) string {
+	return s.submitTime.Token(articleID, time.Now())
+}
+
+// 分页获取文章的评论树，分页以顶层评论计
+func (s *Service) GetComments(ctx context.Context, articleID, page, pageSize int) ([]*CommentNode, int, error) {
+	comments, total, err := cacheGetComments(ctx, s.RDB, articleID, page, pageSize)
+	if err == nil {
+		return comments, total, nil
+	}
+
+	if err := s.articles.CheckCommentable(ctx, articleID); err != nil {
+		return nil, 0, err
+	}
+
+	comments, total, err = s.loadComments(ctx, articleID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cacheSetComments(ctx, s.RDB, articleID, page, pageSize, comments, total)
+	return comments, total, nil
+}
+
+func (s *Service) loadComments(ctx context.Context, articleID, page, pageSize int) ([]*CommentNode, int, error) {
+	db := s.DB.WithContext(ctx)
+
+	roots, total, err := repoGetRootComments(db, articleID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rootIDs := make([]int, len(roots))
+	for i, c := range roots {
+		rootIDs[i] = c.ID
+	}
+	replies, err := repoGetReplies(db, rootIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return buildTree(roots, replies), total, nil
+}
+
+// 发表评论，管理员和（未开启全部审核时的）登录用户直接通过，其余进入审核队列
+func (s *Service) CreateComment(ctx context.Context, articleID int, req *CommentRequest, c *Commenter) (*Comment, error) {
+	if !validWebsite(req.Website) {
+		return nil, ErrInvalidWebsite
+	}
+	if err := s.articles.CheckCommentable(ctx, articleID); err != nil {
+		return nil, err
+	}
+
+	comment := &Comment{
+		ArticleID:  articleID,
+		UserID:     c.UserID,
+		AuthorName: strings.TrimSpace(req.AuthorName),
+		Email:      req.Email,
+		Website:    req.Website,
+		Content:    req.Content,
+		Status:     CommentPending,
+		IP:         c.IP,
+		UserAgent:  c.UserAgent,
+	}
+	if c.UserID != 0 {
+		comment.AuthorName = c.Name
+	}
+	if comment.AuthorName == "" {
+		return nil, ErrAuthorRequired
+	}
+
+	if req.ParentID != 0 {
+		parent, err := repoGetComment(s.DB.WithContext(ctx), req.ParentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if parent.ArticleID != articleID || parent.Status != CommentApproved {
+			return nil, ErrCommentNotFound
+		}
+
+		comment.ParentID = parent.ID
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID
+		}
+		comment.Depth = parent.Depth + 1
+		if comment.Depth > s.maxDepth() {
+			return nil, ErrTooDeep
+		}
+	}
+
+	if c.IsAdmin || (c.UserID != 0 && !s.conf.ModerateAll) {
+		comment.Status = CommentApproved
+	}
+
+	// 管理员的评论不做垃圾检测
+	var verdict *CommentVerdict
+	if !c.IsAdmin {
+		score, details := s.filter.Check(ctx, &spam.Submission{
+			Content:    comment.Content,
+			AuthorName: comment.AuthorName,
+			Email:      comment.Email,
+			Website:    comment.Website,
+			IP:         comment.IP,
+			UserAgent:  comment.UserAgent,
+			Honeypot:   req.Honeypot,
+			FormToken:  req.FormToken,
+			ArticleID:  articleID,
+
+			Authenticated: c.UserID != 0,
+		})
+		comment.Status = s.route(comment.Status, score)
+		verdict = &CommentVerdict{
+			Source:  VerdictFilter,
+			Score:   score,
+			Status:  comment.Status,
+			Details: details,
+		}
+	}
+
+	if err := repoCreateComment(s.DB.WithContext(ctx), comment); err != nil {
+		return nil, err
+	}
+
+	if verdict != nil {
+		verdict.CommentID = comment.ID
+		if err := repoCreateVerdict(s.DB.WithContext(ctx), verdict); err != nil {
+			log.Printf("保存评论判定记录失败 comment=%d: %v", comment.ID, err)
+		}
+	}
+
+	if comment.Status == CommentApproved {
+		s.articles.TrackComment(ctx, articleID, 1, comment.CreatedAt)
+		s.refresh(ctx, articleID)
+	}
+	return comment, nil
+}
+
+// 审核队列
+func (s *Service) GetCommentsByStatus(ctx context.Context, status CommentStatus, page, pageSize int) ([]ModerationComment, int, error) {
+	comments, total, err := repoGetCommentsByStatus(s.DB.WithContext(ctx), status, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toModeration(comments), total, nil
+}
+
+// 批量修改评论状态，记录审核判定并训练贝叶斯分类器，然后刷新涉及文章的评论数和评论缓存
+func (s *Service) ModerateComments(ctx context.Context, ids []int, status CommentStatus, moderatorID int) error {
+	comments, err := repoGetComments(s.DB.WithContext(ctx), ids)
+	if err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	found := make([]int, len(comments))
+	verdicts := make([]CommentVerdict, len(comments))
+	for i, c := range comments {
+		found[i] = c.ID
+		verdicts[i] = CommentVerdict{
+			CommentID:   c.ID,
+			Source:      VerdictModerator,
+			Status:      status,
+			ModeratorID: moderatorID,
+		}
+	}
+
+	if err := repoUpdateStatus(s.DB.WithContext(ctx), found, status, verdicts); err != nil {
+		return err
+	}
+
+	// 涉及的文章
+	touched := map[int]bool{}
+	for _, c := range comments {
+		s.train(ctx, &c, status)
+		touched[c.ArticleID] = true
+
+		delta := 0
+		switch {
+		case c.Status != CommentApproved && status == CommentApproved:
+			delta = 1
+		case c.Status == CommentApproved && status != CommentApproved:
+			delta = -1
+		}
+		if delta != 0 {
+			s.articles.TrackComment(ctx, c.ArticleID, delta, c.CreatedAt)
+		}
+	}
+	for id := range touched {
+		s.refresh(ctx, id)
+	}
+	return nil
+}
+
+// 评论的判定记录
+func (s *Service) GetVerdicts(ctx context.Context, id int) ([]CommentVerdict, error) {
+	return repoGetVerdicts(s.DB.WithContext(ctx), id)
+}
+
+// train 审核结果改变训练标签时，撤销原标签的训练并按新标签训练
+func (s *Service) train(ctx context.Context, c *Comment, status CommentStatus) {
+	oldSpam, oldOK := trainLabel(c.Status)
+	newSpam, newOK := trainLabel(status)
+	if oldOK == newOK && oldSpam == newSpam {
+		return
+	}
+
+	if oldOK {
+		if err := s.bayes.Train(ctx, c.Content, oldSpam, -1); err != nil {
+			log.Printf("撤销分类器训练失败 comment=%d: %v", c.ID, err)
+		}
+	}
+	if newOK {
+		if err := s.bayes.Train(ctx, c.Content, newSpam, 1); err != nil {
+			log.Printf("训练分类器失败 comment=%d: %v", c.ID, err)
+		}
+	}
+}
+
+// route 按垃圾检测得分调整评论状态
+func (s *Service) route(status CommentStatus, score float64) CommentStatus {
+	spamThreshold := s.conf.Spam.SpamThreshold
+	if spamThreshold <= 0 {
+		spamThreshold = defaultSpamThreshold
+	}
+	reviewThreshold := s.conf.Spam.ReviewThreshold
+	if reviewThreshold <= 0 {
+		reviewThreshold = defaultReviewThreshold
+	}
+
+	switch {
+	case score >= spamThreshold:
+		return CommentSpam
+	case score >= reviewThreshold:
+		return CommentPending
+	}
+	return status
+}
+
+// 删除评论
+func (s *Service) DeleteComment(ctx context.Context, id int, moderatorID int) error {
+	comment, err := repoGetComment(s.DB.WithContext(ctx), id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.ModerateComments(ctx, []int{comment.ID}, CommentDeleted, moderatorID)
+}
+
+// refresh 重新统计文章评论数并清除评论缓存
+func (s *Service) refresh(ctx context.Context, articleID int) {
+	count, err := repoCountApproved(s.DB.WithContext(ctx), articleID)
+	if err != nil {
+		log.Printf("统计评论数失败 article=%d: %v", articleID, err)
+	} else if err := s.articles.SetCommentCount(ctx, articleID, count); err != nil {
+		log.Printf("更新文章评论数失败 article=%d: %v", articleID, err)
+	}
+
+	if err := cacheDelComments(ctx, s.RDB, articleID); err != nil {
+		log.Printf("清除评论缓存失败 article=%d: %v", articleID, err)
+	}
+}
+
+// validWebsite 网站地址会出现在公开的评论中，只允许 http 和 https，避免 javascript: 等链接
+func validWebsite(raw string) bool {
+	if raw == "" {
+		return true
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return scheme == "http" || scheme == "https"
+}
+
+// onArticleEvent 文章删除或离开公开状态后清除评论缓存，否则缓存过期前仍能读到评论
+func (s *Service) onArticleEvent(ctx context.Context, e *event.Event) {
+	if e.Type != event.ArticleDeleted && !e.Listed {
+		return
+	}
+	if err := cacheDelComments(ctx, s.RDB, e.ArticleID); err != nil {
+		log.Printf("清除评论缓存失败 article=%d: %v", e.ArticleID, err)
+	}
+}
+
+func (s *Service) maxDepth() int {
+	if s.conf.MaxDepth != nil && *s.conf.MaxDepth >= 0 {
+		return *s.conf.MaxDepth
+	}
+	return defaultMaxDepth
+}
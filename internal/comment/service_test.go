@@ -0,0 +1,144 @@
+package comment
+
+import (
+	"context"
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/event"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   config.SpamConfig
+		status CommentStatus
+		score  float64
+		want   CommentStatus
+	}{
+		{"默认阈值下缺少 token 不进入审核", config.SpamConfig{}, CommentApproved, 0.3, CommentApproved},
+		{"达到审核阈值", config.SpamConfig{}, CommentApproved, 0.5, CommentPending},
+		{"达到垃圾阈值", config.SpamConfig{}, CommentApproved, 0.9, CommentSpam},
+		{"未达阈值保持原状态", config.SpamConfig{}, CommentPending, 0.1, CommentPending},
+		{"自定义阈值", config.SpamConfig{ReviewThreshold: 0.2, SpamThreshold: 0.6}, CommentApproved, 0.3, CommentPending},
+		{"自定义垃圾阈值", config.SpamConfig{ReviewThreshold: 0.2, SpamThreshold: 0.6}, CommentApproved, 0.6, CommentSpam},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{conf: &config.CommentConfig{Spam: tt.conf}}
+			if got := s.route(tt.status, tt.score); got != tt.want {
+				t.Fatalf("route = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	depth := func(n int) *int { return &n }
+	tests := []struct {
+		name string
+		conf *int
+		want int
+	}{
+		{"未配置", nil, defaultMaxDepth},
+		{"不允许回复", depth(0), 0},
+		{"自定义", depth(5), 5},
+		{"负数使用默认值", depth(-1), defaultMaxDepth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{conf: &config.CommentConfig{MaxDepth: tt.conf}}
+			if got := s.maxDepth(); got != tt.want {
+				t.Errorf("maxDepth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnArticleEvent(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	s := &Service{RDB: rdb}
+
+	tests := []struct {
+		name    string
+		e       *event.Event
+		cleared bool
+	}{
+		{"删除", &event.Event{Type: event.ArticleDeleted, ArticleID: 1}, true},
+		{"转为非公开", &event.Event{Type: event.ArticleUpdated, ArticleID: 1, Listed: true}, true},
+		{"普通修改", &event.Event{Type: event.ArticleUpdated, ArticleID: 1}, false},
+		{"其他文章", &event.Event{Type: event.ArticleDeleted, ArticleID: 2}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cacheSetComments(ctx, rdb, 1, 1, 10, []*CommentNode{}, 0); err != nil {
+				t.Fatal(err)
+			}
+			s.onArticleEvent(ctx, tt.e)
+
+			_, _, err := cacheGetComments(ctx, rdb, 1, 1, 10)
+			if cleared := err == ErrCacheMiss; cleared != tt.cleared {
+				t.Errorf("cleared = %v, want %v (err %v)", cleared, tt.cleared, err)
+			}
+		})
+	}
+}
+
+func TestValidWebsite(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"", true},
+		{"https://example.com", true},
+		{"HTTP://example.com/path?q=1", true},
+		{"javascript:alert(1)", false},
+		{"JavaScript://example.com/%0Aalert(1)", false},
+		{"data:text/html,<script>alert(1)</script>", false},
+		{"ftp://example.com", false},
+		{"//example.com", false},
+		{"http://", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := validWebsite(tt.raw); got != tt.want {
+				t.Errorf("validWebsite(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommentRequestWebsiteBinding(t *testing.T) {
+	tests := []struct {
+		website string
+		valid   bool
+	}{
+		{"", true},
+		{"https://example.com", true},
+		{"javascript:alert(1)", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.website, func(t *testing.T) {
+			req := CommentRequest{Content: "hi", Website: tt.website}
+			err := binding.Validator.ValidateStruct(&req)
+			if valid := err == nil; valid != tt.valid {
+				t.Errorf("valid = %v, want %v (err %v)", valid, tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestCreateCommentRejectsScriptWebsite(t *testing.T) {
+	s := &Service{}
+	_, err := s.CreateComment(context.Background(), 1, &CommentRequest{Content: "hi", Website: "javascript:alert(1)"}, &Commenter{})
+	if err != ErrInvalidWebsite {
+		t.Errorf("err = %v, want ErrInvalidWebsite", err)
+	}
+}
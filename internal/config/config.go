@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +13,12 @@ type Config struct {
 	Httpserver HttpserverConfig `mapstructure:"httpserver"`
 	Database   DatabaseConfig   `mapstructure:"database"`
 	Redis      RedisConfig      `mapstructure:"redis"`
+	Auth       AuthConfig       `mapstructure:"auth"`
+	Article    ArticleConfig    `mapstructure:"article"`
+	Site       SiteConfig       `mapstructure:"site"`
+	Comment    CommentConfig    `mapstructure:"comment"`
+	Analytics  AnalyticsConfig  `mapstructure:"analytics"`
+	Cache      CacheConfig      `mapstructure:"cache"`
 }
 
 type HttpserverConfig struct {
@@ -46,9 +54,137 @@ type RedisConfig struct {
 	Protocol int    `mapstructure:"protocol"`
 }
 
+// AuthConfig 登录与 token 配置
+type AuthConfig struct {
+	Secret          string        `mapstructure:"secret"`          // JWT 签名密钥
+	Issuer          string        `mapstructure:"issuer"`          // JWT 签发者
+	Audience        string        `mapstructure:"audience"`        // JWT 受众
+	AccessTokenTTL  time.Duration `mapstructure:"accessTokenTTL"`  // access token 有效期
+	RefreshTokenTTL time.Duration `mapstructure:"refreshTokenTTL"` // refresh token 有效期
+	AdminUsername   string        `mapstructure:"adminUsername"`   // 启动时创建的管理员账号，为空则不创建
+	AdminPassword   string        `mapstructure:"adminPassword"`
+}
+
+// ArticleConfig 文章模块配置
+type ArticleConfig struct {
+	PublishInterval time.Duration `mapstructure:"publishInterval"` // 定时发布检查间隔
+	PreviewSecret   string        `mapstructure:"previewSecret"`   // 草稿预览链接签名密钥
+	PreviewTTL      time.Duration `mapstructure:"previewTTL"`      // 草稿预览链接默认有效期
+	Reactions       []string      `mapstructure:"reactions"`       // 允许的表情回应
+
+	Popularity PopularityConfig `mapstructure:"popularity"`
+}
+
+// Validate 校验预览链接密钥
+func (c *ArticleConfig) Validate() error {
+	return CheckSecret("article.previewSecret", c.PreviewSecret)
+}
+
+// PopularityConfig 热门排行评分，各项为 0 时使用默认值
+// 每次浏览、点赞、评论和发布按权重加分，分数按半衰期随时间衰减
+type PopularityConfig struct {
+	ViewWeight    float64       `mapstructure:"viewWeight"`    // 每个新访客
+	LikeWeight    float64       `mapstructure:"likeWeight"`    // 每次点赞
+	CommentWeight float64       `mapstructure:"commentWeight"` // 每条通过审核的评论
+	PublishWeight float64       `mapstructure:"publishWeight"` // 发布时的初始分，体现新鲜度
+	HalfLifeDay   time.Duration `mapstructure:"halfLifeDay"`   // 24h 排行的半衰期
+	HalfLifeWeek  time.Duration `mapstructure:"halfLifeWeek"`  // 7d 排行的半衰期
+	HalfLifeMonth time.Duration `mapstructure:"halfLifeMonth"` // 30d 排行的半衰期，all 排行不衰减
+}
+
+// CommentConfig 评论配置
+type CommentConfig struct {
+	MaxDepth    *int `mapstructure:"maxDepth"`    // 最大嵌套层级，顶层为 0，配置为 0 时不允许回复；不配置或为负数时使用默认值
+	ModerateAll bool `mapstructure:"moderateAll"` // 登录用户的评论是否也需要审核
+
+	Spam SpamConfig `mapstructure:"spam"`
+}
+
+// SpamConfig 垃圾评论检测配置
+type SpamConfig struct {
+	Blocklist       []string      `mapstructure:"blocklist"`       // 黑名单关键词，"re:" 开头的按正则匹配
+	MaxLinks        int           `mapstructure:"maxLinks"`        // 允许的链接数
+	MinSubmitTime   time.Duration `mapstructure:"minSubmitTime"`   // 从渲染表单到提交的最短耗时
+	FormSecret      string        `mapstructure:"formSecret"`      // 表单 token 签名密钥
+	SpamThreshold   float64       `mapstructure:"spamThreshold"`   // 得分不低于该值直接标记为垃圾
+	ReviewThreshold float64       `mapstructure:"reviewThreshold"` // 得分不低于该值进入审核队列
+}
+
+// Validate 校验表单 token 签名密钥
+func (c *CommentConfig) Validate() error {
+	return CheckSecret("comment.spam.formSecret", c.Spam.FormSecret)
+}
+
+// AnalyticsConfig 浏览统计配置
+type AnalyticsConfig struct {
+	RollupInterval time.Duration `mapstructure:"rollupInterval"` // 从 Redis 汇总到数据库的间隔
+	Hourly         bool          `mapstructure:"hourly"`         // 是否同时记录小时粒度
+
+	Bot BotConfig `mapstructure:"bot"`
+}
+
+// BotConfig 爬虫识别配置，识别出的浏览不计入文章浏览量和热门排行
+type BotConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	CountBots  bool          `mapstructure:"countBots"`  // 是否单独统计爬虫浏览，否则直接忽略
+	Crawlers   []string      `mapstructure:"crawlers"`   // 内置列表之外的爬虫 UA 关键字
+	Patterns   []string      `mapstructure:"patterns"`   // 内置规则之外的 UA 正则
+	Headless   bool          `mapstructure:"headless"`   // 是否检测无头浏览器特征
+	RateLimit  int           `mapstructure:"rateLimit"`  // 单个 IP 每个窗口内最多浏览次数，0 表示不限制
+	RateWindow time.Duration `mapstructure:"rateWindow"` // 频率计数窗口
+}
+
+// CacheConfig 缓存配置
+type CacheConfig struct {
+	// L1 进程内缓存，按缓存名称（如 article、page）配置，未配置的不启用
+	L1 map[string]L1CacheConfig `mapstructure:"l1"`
+}
+
+// L1CacheConfig 某类缓存的进程内缓存配置
+type L1CacheConfig struct {
+	Size int           `mapstructure:"size"` // 最多缓存的条目数，0 表示不启用
+	TTL  time.Duration `mapstructure:"ttl"`  // 过期时间，其他实例的修改最迟在此之后可见
+}
+
+// Local 获取某类缓存的进程内缓存配置，viper 读取的 map key 均为小写
+func (c *CacheConfig) Local(name string) L1CacheConfig {
+	return c.L1[strings.ToLower(name)]
+}
+
+// SiteConfig 站点信息，用于订阅源等对外输出
+type SiteConfig struct {
+	Title       string `mapstructure:"title"`
+	URL         string `mapstructure:"url"` // 站点根地址，不带末尾 /
+	Description string `mapstructure:"description"`
+	Author      string `mapstructure:"author"`
+	Language    string `mapstructure:"language"`
+	ArticlePath string `mapstructure:"articlePath"` // 文章页路径，{slug} 和 {id} 会被替换
+	FeedLimit   int    `mapstructure:"feedLimit"`   // 订阅源文章数
+
+	SitemapInterval time.Duration `mapstructure:"sitemapInterval"` // sitemap 定时重建间隔
+	Robots          []RobotsRule  `mapstructure:"robots"`          // robots.txt 规则，为空时只禁止抓取 /api/
+}
+
+// RobotsRule robots.txt 中的一组规则
+type RobotsRule struct {
+	UserAgents []string `mapstructure:"userAgents"` // 为空表示 *
+	Allow      []string `mapstructure:"allow"`
+	Disallow   []string `mapstructure:"disallow"`
+	CrawlDelay int      `mapstructure:"crawlDelay"`
+}
+
+// 环境变量覆盖配置文件，如 BLOG_AUTH_SECRET 对应 auth.secret
+// 只对配置文件中出现的配置项生效，密钥类配置在配置文件中留空，部署时通过环境变量设置
+const envPrefix = "BLOG"
+
+var envReplacer = strings.NewReplacer(".", "_")
+
 // ReadConfig 读取配置文件
 func ReadConfig(fpath, fname, ftype string) (*Config, error) {
 	viper.Reset()
+	viper.SetEnvPrefix(envPrefix)
+	viper.SetEnvKeyReplacer(envReplacer)
+	viper.AutomaticEnv()
 
 	viper.AddConfigPath(fpath)
 	viper.SetConfigName(fname)
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MinSecretLen 签名密钥的最短字节数
+const MinSecretLen = 32
+
+// placeholderSecrets 示例配置中用过的占位密钥，部署时必须替换
+var placeholderSecrets = []string{
+	"your_secret_key",
+	"your_preview_secret",
+	"your_form_secret",
+	"secret",
+	"changeme",
+}
+
+// CheckSecret 校验签名密钥：不能为空、不能是占位值、不能短于 MinSecretLen
+// name 为配置项名称，用于错误信息
+func CheckSecret(name, secret string) error {
+	if secret == "" {
+		return fmt.Errorf("%s 不能为空，请通过环境变量 %s 设置", name, EnvName(name))
+	}
+	if slices.Contains(placeholderSecrets, strings.ToLower(secret)) {
+		return fmt.Errorf("%s 仍是示例配置中的占位值", name)
+	}
+	if len(secret) < MinSecretLen {
+		return fmt.Errorf("%s 至少需要 %d 字节", name, MinSecretLen)
+	}
+	return nil
+}
+
+// EnvName 配置项对应的环境变量名，如 auth.secret -> BLOG_AUTH_SECRET
+func EnvName(key string) string {
+	return envPrefix + "_" + envReplacer.Replace(strings.ToUpper(key))
+}
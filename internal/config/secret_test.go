@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		ok     bool
+	}{
+		{"空", "", false},
+		{"占位值", "your_secret_key", false},
+		{"占位值大小写", "YOUR_PREVIEW_SECRET", false},
+		{"表单占位值", "your_form_secret", false},
+		{"过短", "short-but-not-a-placeholder", false},
+		{"31 字节", strings.Repeat("a", MinSecretLen-1), false},
+		{"32 字节", strings.Repeat("a", MinSecretLen), true},
+		{"长随机值", "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSecret("auth.secret", tt.secret)
+			if tt.ok != (err == nil) {
+				t.Fatalf("CheckSecret(%q) = %v, want ok = %v", tt.secret, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("comment.spam.formSecret"); got != "BLOG_COMMENT_SPAM_FORMSECRET" {
+		t.Fatalf("EnvName = %s", got)
+	}
+}
@@ -0,0 +1,151 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	ArticleCreated   Type = "article.created"
+	ArticleUpdated   Type = "article.updated"
+	ArticleDeleted   Type = "article.deleted"
+	ArticlePublished Type = "article.published" // 定时发布到期，或由非公开转为公开
+)
+
+// Event 领域事件
+type Event struct {
+	Type      Type     `json:"type"`
+	ArticleID int      `json:"articleId"`
+	Tags      []string `json:"tags,omitempty"` // 涉及的标签，修改标签时包含修改前后的全部标签
+	// Listed 公开列表是否变化，即文章进入或离开公开状态
+	Listed bool `json:"listed"`
+	// StatsOnly 只有评论数等统计字段变化，内容和地址不变
+	StatsOnly bool `json:"statsOnly,omitempty"`
+
+	At     time.Time `json:"at"`
+	Origin string    `json:"origin"` // 发布事件的实例
+}
+
+// Handler 事件处理函数，出错时自行记录日志
+type Handler func(ctx context.Context, e *Event)
+
+// Bus 进程内事件总线，同时通过 Redis pub/sub 广播给其他实例
+//
+// Subscribe 注册的处理函数只在发布事件的实例上执行一次，用于清除 Redis 缓存等共享状态；
+// SubscribeAll 注册的处理函数在每个实例上都会执行，用于清除进程内缓存等本地状态
+type Bus struct {
+	rdb    *redis.Client
+	origin string
+
+	mu    sync.RWMutex
+	once  map[Type][]Handler
+	every map[Type][]Handler
+}
+
+func NewBus(rdb *redis.Client) *Bus {
+	return &Bus{
+		rdb:    rdb,
+		origin: instanceID(),
+		once:   map[Type][]Handler{},
+		every:  map[Type][]Handler{},
+	}
+}
+
+// instanceID 主机名加随机后缀，区分同一主机上的多个实例
+func instanceID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+// Subscribe 注册只在发布事件的实例上执行的处理函数
+func (b *Bus) Subscribe(h Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range types {
+		b.once[t] = append(b.once[t], h)
+	}
+}
+
+// SubscribeAll 注册在每个实例上都执行的处理函数
+func (b *Bus) SubscribeAll(h Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, t := range types {
+		b.every[t] = append(b.every[t], h)
+	}
+}
+
+// Publish 在当前实例上同步执行处理函数，再广播给其他实例，广播失败只记录日志
+func (b *Bus) Publish(ctx context.Context, e *Event) {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	e.Origin = b.origin
+
+	b.dispatch(ctx, e, b.once)
+	b.dispatch(ctx, e, b.every)
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("序列化事件失败 type=%s: %v", e.Type, err)
+		return
+	}
+	if err := b.rdb.Publish(ctx, EventChannelKey(), data).Err(); err != nil {
+		log.Printf("广播事件失败 type=%s id=%d: %v", e.Type, e.ArticleID, err)
+	}
+}
+
+// Start 订阅其他实例广播的事件，ctx 取消后停止
+func (b *Bus) Start(ctx context.Context) {
+	sub := b.rdb.Subscribe(ctx, EventChannelKey())
+
+	go func() {
+		defer sub.Close()
+
+		ch := sub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				var e Event
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+					log.Printf("解析事件失败: %v", err)
+					continue
+				}
+				// 自己发布的事件已在 Publish 中处理
+				if e.Origin == b.origin {
+					continue
+				}
+				b.dispatch(ctx, &e, b.every)
+			}
+		}
+	}()
+}
+
+func (b *Bus) dispatch(ctx context.Context, e *Event, handlers map[Type][]Handler) {
+	b.mu.RLock()
+	hs := handlers[e.Type]
+	b.mu.RUnlock()
+
+	for _, h := range hs {
+		h(ctx, e)
+	}
+}
@@ -0,0 +1,6 @@
+package event
+
+// EventChannelKey 领域事件广播的 Redis pub/sub 频道
+func EventChannelKey() string {
+	return "Event:Bus"
+}
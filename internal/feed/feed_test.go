@@ -0,0 +1,79 @@
+package feed
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() *Feed {
+	at := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:    "Blog",
+		Link:     "https://example.com",
+		FeedLink: "https://example.com/feed.xml",
+		Updated:  at,
+		Items: []Item{
+			{ID: "tag:example.com,2024-05-01:article:1", Title: "有正文", Link: "https://example.com/a", ContentHTML: "<p>hi</p>", Tags: []string{"go"}, Published: at, Updated: at},
+			{ID: "tag:example.com,2024-05-01:article:2", Title: "无正文", Link: "https://example.com/b", Published: at, Updated: at},
+		},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	data, err := RSS(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+
+	tests := []struct {
+		name string
+		want string
+		n    int
+	}{
+		{"只有有正文的条目输出 content:encoded", "<content:encoded>", 1},
+		{"正文使用 CDATA", "<![CDATA[<p>hi</p>]]>", 1},
+		{"GUID 不是永久链接", `<guid isPermaLink="false">tag:example.com,2024-05-01:article:`, 2},
+		{"分类", "<category>go</category>", 1},
+	}
+	for _, tt := range tests {
+		if n := strings.Count(out, tt.want); n != tt.n {
+			t.Errorf("%s: %q 出现 %d 次, want %d\n%s", tt.name, tt.want, n, tt.n, out)
+		}
+	}
+}
+
+func TestAtom(t *testing.T) {
+	data, err := Atom(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+
+	if n := strings.Count(out, `<content type="html">`); n != 1 {
+		t.Errorf("content 出现 %d 次, want 1", n)
+	}
+	if !strings.Contains(out, "<id>tag:example.com,2024-05-01:article:2</id>") {
+		t.Errorf("缺少条目 id\n%s", out)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := JSON(testFeed())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got jsonFeed
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Items) != 2 || got.Items[0].ContentHTML != "<p>hi</p>" || got.Items[1].ContentHTML != "" {
+		t.Fatalf("items = %+v", got.Items)
+	}
+	if got.Items[1].ID != "tag:example.com,2024-05-01:article:2" {
+		t.Fatalf("id = %s", got.Items[1].ID)
+	}
+}
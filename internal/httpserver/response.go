@@ -17,6 +17,11 @@ func (h *BaseHandler) Fail(c *gin.Context, r Result, data any) {
 	ReturnResponse(c, r, data)
 }
 
+// FailStatus 以指定的 HTTP 状态码返回错误
+func (h *BaseHandler) FailStatus(c *gin.Context, httpcode int, r Result, data any) {
+	ReturnHttpResponse(c, httpcode, r.Code(), r.Msg(), data)
+}
+
 func (h *BaseHandler) Response(c *gin.Context, r Result, data any) {
 	ReturnResponse(c, r, data)
 }
@@ -57,4 +57,22 @@ var (
 
 	ErrPassword  = RegisterResult(2001, "密码错误")
 	ErrUserExist = RegisterResult(2002, "用户不存在")
+	ErrToken     = RegisterResult(2003, "登录状态无效")
+
+	ErrArticleNotFound  = RegisterResult(3001, "文章不存在")
+	ErrArticleForbidden = RegisterResult(3002, "无权访问该文章")
+	ErrArticleStatus    = RegisterResult(3003, "文章状态转换不合法")
+	ErrPublishAt        = RegisterResult(3004, "定时发布时间无效")
+	ErrPreviewLink      = RegisterResult(3005, "预览链接无效或已过期")
+	ErrRevisionNotFound = RegisterResult(3006, "修订记录不存在")
+	ErrSlug             = RegisterResult(3007, "slug 格式不合法")
+	SlugMovedResult     = RegisterResult(3008, "文章地址已变更")
+	ErrReaction         = RegisterResult(3009, "不支持的表情回应")
+
+	ErrCommentNotFound = RegisterResult(4001, "评论不存在")
+	ErrCommentDepth    = RegisterResult(4002, "评论嵌套层级过深")
+	ErrCommentAuthor   = RegisterResult(4003, "请填写昵称")
+	ErrCommentWebsite  = RegisterResult(4004, "网站地址只支持 http 和 https")
+
+	ErrDateRange = RegisterResult(5001, "统计日期区间无效")
 )
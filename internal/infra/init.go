@@ -3,8 +3,11 @@ package infra
 import (
 	"fmt"
 	"log"
+	"my_web/backend/internal/analytics"
 	"my_web/backend/internal/article"
+	"my_web/backend/internal/comment"
 	"my_web/backend/internal/config"
+	"my_web/backend/internal/user"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/postgres"
@@ -26,10 +29,40 @@ func InitDatabase(conf *config.DatabaseConfig) (*gorm.DB, error) {
 	// 自动迁移
 	if err := db.AutoMigrate(
 		&article.Article{},
+		&article.Tag{},
+		&article.ArticleRevision{},
+		&article.SlugHistory{},
+		&article.Bookmark{},
+		&article.Reaction{},
+		&article.ViewBatch{},
+		&user.User{},
+		&comment.Comment{},
+		&comment.CommentVerdict{},
+		&analytics.DailyStat{},
+		&analytics.HourlyStat{},
+		&analytics.ReferrerStat{},
+		&analytics.BotStat{},
+		&analytics.CampaignStat{},
 	); err != nil {
 		return nil, fmt.Errorf("数据库自动迁移失败: %w", err)
 	}
 
+	if err := article.InitSearchIndex(db); err != nil {
+		return nil, fmt.Errorf("初始化全文检索索引失败: %w", err)
+	}
+
+	if err := article.BackfillTags(db); err != nil {
+		return nil, fmt.Errorf("回填文章标签失败: %w", err)
+	}
+
+	if err := article.BackfillSlugs(db); err != nil {
+		return nil, fmt.Errorf("生成文章 slug 失败: %w", err)
+	}
+
+	if err := article.BackfillRendered(db); err != nil {
+		return nil, fmt.Errorf("渲染文章 HTML 失败: %w", err)
+	}
+
 	log.Println("数据库初始化成功")
 	return db, nil
 }
@@ -0,0 +1,186 @@
+package markdown
+
+import (
+	"bytes"
+	"math"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	cjkCharsPerMinute = 300 // 中文阅读速度（字/分钟）
+	wordsPerMinute    = 200 // 英文阅读速度（词/分钟）
+)
+
+// Version 渲染及统计规则的版本，规则变化时加 1，已保存的旧版本结果会被重新生成
+const Version = 1
+
+// Heading 目录项
+type Heading struct {
+	Level    int       `json:"level"`
+	ID       string    `json:"id"`
+	Text     string    `json:"text"`
+	Children []Heading `json:"children,omitempty"`
+}
+
+// Document 渲染结果及正文统计
+type Document struct {
+	HTML        string
+	TOC         []Heading
+	WordCount   int // 字数：每个汉字计 1，连续的字母数字计 1
+	CharCount   int // 非空白字符数
+	ReadingTime int // 预计阅读分钟数，有内容时至少为 1
+}
+
+// Process 解析 Markdown，一次生成 HTML、目录和字数统计
+// 统计只计正文文本，不含代码块和行内代码
+func Process(source string) (*Document, error) {
+	src := []byte(source)
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	root := md.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	doc := &Document{}
+	headings := []Heading{}
+	var stats textStats
+
+	err := ast.Walk(root, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		switch node := n.(type) {
+		case *ast.Heading:
+			id, _ := node.AttributeString("id")
+			idBytes, _ := id.([]byte)
+			headings = append(headings, Heading{
+				Level: node.Level,
+				ID:    string(idBytes),
+				Text:  string(nodeText(node, src)),
+			})
+		case *ast.FencedCodeBlock, *ast.CodeBlock, *ast.CodeSpan:
+			stats.brk()
+			return ast.WalkSkipChildren, nil
+		case *ast.Text:
+			stats.add(node.Segment.Value(src))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				stats.brk()
+			}
+			return ast.WalkContinue, nil
+		}
+		// 块之间断词；同一块内被强调、链接等拆开的文本连续计数
+		if n.Type() == ast.TypeBlock {
+			stats.brk()
+		}
+		return ast.WalkContinue, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, src, root); err != nil {
+		return nil, err
+	}
+
+	doc.HTML = policy.Sanitize(buf.String())
+	doc.TOC = buildTOC(headings)
+	doc.WordCount = stats.cjk + stats.words
+	doc.CharCount = stats.chars
+	doc.ReadingTime = stats.minutes()
+	return doc, nil
+}
+
+// nodeText 拼接节点下所有文本
+func nodeText(n ast.Node, src []byte) []byte {
+	var buf bytes.Buffer
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		if t, ok := c.(*ast.Text); ok {
+			buf.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() {
+				buf.WriteByte(' ')
+			}
+			continue
+		}
+		buf.Write(nodeText(c, src))
+	}
+	return buf.Bytes()
+}
+
+// buildTOC 将平铺的标题按级别组织为树，跳级的标题挂在最近的上级下
+func buildTOC(flat []Heading) []Heading {
+	var build func(items []Heading) []Heading
+	build = func(items []Heading) []Heading {
+		out := []Heading{}
+		for i := 0; i < len(items); {
+			h := items[i]
+			j := i + 1
+			for j < len(items) && items[j].Level > h.Level {
+				j++
+			}
+			if j > i+1 {
+				h.Children = build(items[i+1 : j])
+			}
+			out = append(out, h)
+			i = j
+		}
+		return out
+	}
+	return build(flat)
+}
+
+// textStats 累计正文统计，inWord 跨文本节点保留，
+// 如 "foo**bar**" 拆成两个文本节点时仍计为一个词
+type textStats struct {
+	cjk    int
+	words  int
+	chars  int
+	inWord bool
+}
+
+// brk 结束当前的词，在块边界、换行和跳过的代码处调用
+func (s *textStats) brk() {
+	s.inWord = false
+}
+
+func (s *textStats) add(b []byte) {
+	for _, r := range string(b) {
+		if !unicode.IsSpace(r) {
+			s.chars++
+		}
+
+		switch {
+		case isCJK(r):
+			s.cjk++
+			s.inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !s.inWord {
+				s.words++
+				s.inWord = true
+			}
+		default:
+			// 英文缩写和连字符不拆词
+			if s.inWord && (r == '\'' || r == '-') {
+				continue
+			}
+			s.inWord = false
+		}
+	}
+}
+
+func (s *textStats) minutes() int {
+	if s.cjk+s.words == 0 {
+		return 0
+	}
+	minutes := float64(s.cjk)/cjkCharsPerMinute + float64(s.words)/wordsPerMinute
+	return int(math.Ceil(minutes))
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,62 @@
+package markdown
+
+import "testing"
+
+func TestProcessWordCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		words int
+		chars int
+	}{
+		{"空", "", 0, 0},
+		{"英文", "Hello, world!", 2, 12},
+		{"中文", "你好，世界", 4, 5},
+		{"中英混排", "Go 语言", 3, 4},
+		{"缩写和连字符", "don't re-use", 2, 11},
+		{"强调拆开的词", "foo**bar** baz", 2, 9},
+		{"链接拆开的词", "pre[fix](http://example.com)ed", 1, 8},
+		{"软换行断词", "foo\nbar", 2, 6},
+		{"段落之间断词", "foo\n\nbar", 2, 6},
+		{"列表项之间断词", "- foo\n- bar", 2, 6},
+		{"标题", "# Title\n\ntext", 2, 9},
+		{"跳过行内代码", "use `fmt.Println` here", 2, 7},
+		{"跳过代码块", "text\n\n```go\nfunc main() {}\n```\n", 1, 4},
+		{"行内代码处断词", "a`x`b", 2, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Process(tt.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.WordCount != tt.words {
+				t.Errorf("WordCount = %d, want %d", doc.WordCount, tt.words)
+			}
+			if doc.CharCount != tt.chars {
+				t.Errorf("CharCount = %d, want %d", doc.CharCount, tt.chars)
+			}
+		})
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats textStats
+		want  int
+	}{
+		{"无内容", textStats{}, 0},
+		{"不足一分钟", textStats{words: 10}, 1},
+		{"英文", textStats{words: 401}, 3},
+		{"中文", textStats{cjk: 600}, 2},
+		{"混合", textStats{cjk: 300, words: 200}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.minutes(); got != tt.want {
+				t.Errorf("minutes() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+)
+
+// headingIDs 生成标题锚点：保留各语言的字母和数字，空白转为 -，重复时追加序号
+// goldmark 默认实现会丢弃中文字符
+type headingIDs struct {
+	used map[string]int
+}
+
+var _ parser.IDs = (*headingIDs)(nil)
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: map[string]int{}}
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	dash := false
+	for _, r := range string(value) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(unicode.ToLower(r))
+			dash = false
+		case (unicode.IsSpace(r) || r == '-' || r == '_') && sb.Len() > 0 && !dash:
+			sb.WriteByte('-')
+			dash = true
+		}
+	}
+
+	id := strings.TrimSuffix(sb.String(), "-")
+	if id == "" {
+		id = "heading"
+	}
+
+	if n, ok := s.used[id]; ok {
+		s.used[id] = n + 1
+		id = id + "-" + strconv.Itoa(n+1)
+	}
+	s.used[id] = 0
+	return []byte(id)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = 0
+}
@@ -0,0 +1,51 @@
+package markdown
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+// md CommonMark + GFM，代码块按 class 高亮（样式由前端主题提供），标题自动生成 id（见 headingIDs）
+// 原始 HTML 先原样输出，再统一交给 policy 过滤
+var md = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithFormatOptions(
+				chromahtml.WithClasses(true),
+			),
+		),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+	),
+	goldmark.WithRendererOptions(
+		html.WithUnsafe(),
+	),
+)
+
+// policy 在 UGC 白名单基础上放行高亮所需的 class 和标题锚点 id
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-zA-Z0-9\s\-_]+$`)).OnElements("pre", "code", "span", "div")
+	p.AllowAttrs("id").Matching(bluemonday.Paragraph).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	return p
+}()
+
+// Render 将 Markdown 渲染为经过白名单过滤的 HTML
+func Render(source string) (string, error) {
+	doc, err := Process(source)
+	if err != nil {
+		return "", err
+	}
+	return doc.HTML, nil
+}
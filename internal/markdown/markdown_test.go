@@ -0,0 +1,53 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitize(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   []string // 输出中必须包含
+		reject []string // 输出中不能包含
+	}{
+		{"script 标签", "<script>alert(1)</script>\n\n正文", []string{"<p>正文</p>"}, []string{"<script", "alert"}},
+		{"行内 script", "文字 <script>alert(1)</script>", nil, []string{"<script", "alert"}},
+		{"事件属性", `<img src="x.png" onerror="alert(1)">`, []string{`<img src="x.png">`}, []string{"onerror", "alert"}},
+		{"事件属性大小写混写", `<a href="https://example.com" OnClick="alert(1)">x</a>`, []string{`href="https://example.com"`}, []string{"onclick", "OnClick", "alert"}},
+		{"Markdown javascript 链接", "[点我](javascript:alert(1))", []string{"点我"}, []string{"javascript:", "href"}},
+		{"HTML javascript 链接", `<a href="JaVaScRiPt:alert(1)">x</a>`, nil, []string{"javascript:", "JaVaScRiPt", "href"}},
+		{"javascript 图片", "![x](javascript:alert(1))", nil, []string{"javascript:"}},
+		{"iframe", `<iframe src="https://example.com"></iframe>`, nil, []string{"<iframe"}},
+		{"style 属性", `<p style="color:red">x</p>`, []string{"<p>x</p>"}, []string{"style"}},
+		{"class 含非法字符", `<span class="a;b">x</span>`, []string{"<span>x</span>"}, []string{"class"}},
+		{"class 含引号", `<code class='x" onclick="alert(1)'>x</code>`, nil, []string{"class", "onclick"}},
+		{"不允许 class 的元素", `<p class="note">x</p>`, []string{"<p>x</p>"}, []string{"class"}},
+		{"id 不合规则", `<h2 id="&lt;&gt;">x</h2>`, []string{"<h2>x</h2>"}, []string{"id="}},
+
+		{"普通链接加 nofollow", "[x](https://example.com)", []string{`<a href="https://example.com" rel="nofollow">x</a>`}, nil},
+		{"高亮 class 保留", "```go\nfunc main() {}\n```", []string{`<pre class="chroma">`, `<span class="kd">func</span>`}, nil},
+		{"合法 class 保留", `<span class="highlight">x</span>`, []string{`<span class="highlight">x</span>`}, nil},
+		{"标题锚点保留", "## Go 语言\n", []string{`<h2 id="go-语言">Go 语言</h2>`}, nil},
+		{"任务列表保留", "- [x] 完成", []string{`<input checked="" disabled="" type="checkbox">`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.src)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("Render(%q) = %q, want containing %q", tt.src, got, s)
+				}
+			}
+			for _, s := range tt.reject {
+				if strings.Contains(got, s) {
+					t.Errorf("Render(%q) = %q, must not contain %q", tt.src, got, s)
+				}
+			}
+		})
+	}
+}
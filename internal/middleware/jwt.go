@@ -1,32 +1,198 @@
 package middleware
 
 import (
+	"errors"
+	"my_web/backend/internal/config"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte("your_secret_key")
+var (
+	jwtKey         []byte // 由 InitJWT 设置，未初始化时拒绝签发和校验
+	jwtIssuer      = ""
+	jwtAudience    = ""
+	accessTokenTTL = 15 * time.Minute
+)
+
+// ErrJWTNotInitialized 未调用 InitJWT 设置密钥
+var ErrJWTNotInitialized = errors.New("JWT 密钥未初始化")
+
+// 只接受 HS256 签名，防止 alg 被替换为 none 或非对称算法
+var jwtMethod = jwt.SigningMethodHS256
+
+const (
+	// ClaimsKey gin 上下文中保存 *Claims 的键
+	ClaimsKey = "claims"
+	// UserIDKey gin 上下文中保存用户ID（字符串）的键
+	UserIDKey = "userID"
+)
+
+// Claims access token 中携带的声明
+type Claims struct {
+	Name  string   `json:"name"`
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+// UserID 将 sub 解析为用户ID，解析失败返回 0
+func (c *Claims) UserID() int {
+	id, _ := strconv.Atoi(c.Subject)
+	return id
+}
+
+// HasRole 是否拥有任一给定角色
+func (c *Claims) HasRole(roles ...string) bool {
+	for _, have := range c.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// InitJWT 使用配置设置签名密钥、签发者、受众和有效期
+func InitJWT(conf *config.AuthConfig) error {
+	if err := config.CheckSecret("auth.secret", conf.Secret); err != nil {
+		return err
+	}
+
+	jwtKey = []byte(conf.Secret)
+	jwtIssuer = conf.Issuer
+	jwtAudience = conf.Audience
+	if conf.AccessTokenTTL > 0 {
+		accessTokenTTL = conf.AccessTokenTTL
+	}
+	return nil
+}
+
+// GenerateToken 为用户签发 access token
+func GenerateToken(userID int, name string, roles []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(accessTokenTTL)
+
+	claims := &Claims{
+		Name:  name,
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    jwtIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	if jwtAudience != "" {
+		claims.Audience = jwt.ClaimStrings{jwtAudience}
+	}
+
+	if len(jwtKey) == 0 {
+		return "", time.Time{}, ErrJWTNotInitialized
+	}
+	signed, err := jwt.NewWithClaims(jwtMethod, claims).SignedString(jwtKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseToken 校验签名算法、有效期、签发者和受众并解析 claims
+func ParseToken(tokenString string) (*Claims, error) {
+	if len(jwtKey) == 0 {
+		return nil, ErrJWTNotInitialized
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwtMethod.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if jwtIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(jwtIssuer))
+	}
+	if jwtAudience != "" {
+		opts = append(opts, jwt.WithAudience(jwtAudience))
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtKey, nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.Subject == "" {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+
+	return claims, nil
+}
+
+// GetClaims 获取当前请求的 claims，未登录返回 false
+func GetClaims(c *gin.Context) (*Claims, bool) {
+	v, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := v.(*Claims)
+	return claims, ok
+}
+
+// ClaimName 返回 token 中的用户名，没有 name 时退回 sub
+func ClaimName(c *gin.Context) string {
+	claims, ok := GetClaims(c)
+	if !ok {
+		return ""
+	}
+	if claims.Name != "" {
+		return claims.Name
+	}
+	return claims.Subject
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer ")), true
+}
+
+func setClaims(c *gin.Context, claims *Claims) {
+	c.Set(ClaimsKey, claims)
+	c.Set(UserIDKey, claims.Subject)
+}
 
 func JWTAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		// 修复版：backend/internal/middleware/jwt.go
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		tokenString, ok := bearerToken(c)
+		if !ok {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
 			return
 		}
-		tokenString := strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
-		if err != nil || !token.Valid {
+		claims, err := ParseToken(tokenString)
+		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			return
 		}
+		setClaims(c, claims)
+		c.Next()
+	}
+}
+
+// OptionalJWTAuth 携带有效 token 时解析身份，否则按匿名用户继续
+func OptionalJWTAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokenString, ok := bearerToken(c); ok {
+			if claims, err := ParseToken(tokenString); err == nil {
+				setClaims(c, claims)
+			}
+		}
 		c.Next()
 	}
 }
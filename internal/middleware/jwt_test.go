@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"my_web/backend/internal/config"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestInitJWT(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		ok     bool
+	}{
+		{"空密钥", "", false},
+		{"旧默认值", "your_secret_key", false},
+		{"过短", "0123456789", false},
+		{"有效", strings.Repeat("k", config.MinSecretLen), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtKey = nil
+			err := InitJWT(&config.AuthConfig{Secret: tt.secret})
+			if tt.ok != (err == nil) {
+				t.Fatalf("InitJWT(%q) = %v, want ok = %v", tt.secret, err, tt.ok)
+			}
+			if !tt.ok && len(jwtKey) != 0 {
+				t.Fatal("被拒绝的密钥不应生效")
+			}
+		})
+	}
+}
+
+func TestTokenRequiresInit(t *testing.T) {
+	jwtKey = nil
+	if _, _, err := GenerateToken(1, "a", nil); err != ErrJWTNotInitialized {
+		t.Fatalf("GenerateToken err = %v", err)
+	}
+	if _, err := ParseToken("x.y.z"); err != ErrJWTNotInitialized {
+		t.Fatalf("ParseToken err = %v", err)
+	}
+
+	if err := InitJWT(&config.AuthConfig{Secret: strings.Repeat("k", config.MinSecretLen)}); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := GenerateToken(7, "a", []string{"admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID() != 7 || !claims.HasRole("admin") {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
+
+func TestParseTokenRejects(t *testing.T) {
+	secret := strings.Repeat("k", config.MinSecretLen)
+	if err := InitJWT(&config.AuthConfig{Secret: secret, Issuer: "blog", Audience: "web"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { jwtIssuer, jwtAudience = "", "" })
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := func(modify func(c *Claims)) *Claims {
+		c := &Claims{
+			Name: "a",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "1",
+				Issuer:    "blog",
+				Audience:  jwt.ClaimStrings{"web"},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+	sign := func(method jwt.SigningMethod, key any, c *Claims) string {
+		t.Helper()
+		token, err := jwt.NewWithClaims(method, c).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"有效", sign(jwt.SigningMethodHS256, []byte(secret), claims(nil)), true},
+		{"alg none", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(nil)), false},
+		{"HS512", sign(jwt.SigningMethodHS512, []byte(secret), claims(nil)), false},
+		{"RS256", sign(jwt.SigningMethodRS256, rsaKey, claims(nil)), false},
+		{"密钥错误", sign(jwt.SigningMethodHS256, []byte(strings.Repeat("x", config.MinSecretLen)), claims(nil)), false},
+		{"签发者错误", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.Issuer = "other" })), false},
+		{"缺少签发者", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.Issuer = "" })), false},
+		{"受众错误", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.Audience = jwt.ClaimStrings{"admin"} })), false},
+		{"缺少受众", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.Audience = nil })), false},
+		{"已过期", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) })), false},
+		{"缺少过期时间", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.ExpiresAt = nil })), false},
+		{"缺少 sub", sign(jwt.SigningMethodHS256, []byte(secret), claims(func(c *Claims) { c.Subject = "" })), false},
+		{"格式错误", "not.a.token", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseToken(tt.token)
+			if tt.ok {
+				if err != nil || got.UserID() != 1 {
+					t.Fatalf("ParseToken = %+v, %v, want ok", got, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ParseToken accepted %s token: %+v", tt.name, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleReader = "reader"
+)
+
+const (
+	PermArticleWrite  = "article:write"
+	PermArticleDelete = "article:delete"
+
+	PermCommentModerate = "comment:moderate"
+
+	PermAnalyticsRead = "analytics:read"
+)
+
+// rolePermissions 角色拥有的权限，admin 拥有全部权限
+var rolePermissions = map[string][]string{
+	RoleEditor: {PermArticleWrite, PermCommentModerate},
+	RoleReader: {},
+}
+
+// HasPermission 判断 claims 中任一角色是否拥有该权限
+func HasPermission(claims *Claims, perm string) bool {
+	for _, role := range claims.Roles {
+		if role == RoleAdmin {
+			return true
+		}
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RequireRole 要求当前用户拥有任一给定角色，需在 JWTAuth 之后使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+			return
+		}
+		if !claims.HasRole(roles...) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission 要求当前用户拥有全部给定权限，需在 JWTAuth 之后使用
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+			return
+		}
+		for _, perm := range perms {
+			if !HasPermission(claims, perm) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+				return
+			}
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,144 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxURLs 单个 sitemap 文件最多包含的 URL 数，超过时需使用 sitemap index
+const MaxURLs = 50000
+
+const xmlns = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []urlEntry
+}
+
+type urlEntry struct {
+	XMLName xml.Name `xml:"url"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []indexEntry
+}
+
+type indexEntry struct {
+	XMLName xml.Name `xml:"sitemap"`
+	Loc     string   `xml:"loc"`
+	LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// URLSet 生成 <urlset>，调用方需保证 len(urls) <= MaxURLs
+func URLSet(urls []URL) ([]byte, error) {
+	set := urlSet{XMLNS: xmlns}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, urlEntry{Loc: u.Loc, LastMod: formatTime(u.LastMod)})
+	}
+	return marshalXML(set)
+}
+
+// Index 生成 <sitemapindex>，sitemaps 中的 LastMod 为对应分片内最新的修改时间
+func Index(sitemaps []URL) ([]byte, error) {
+	index := sitemapIndex{XMLNS: xmlns}
+	for _, s := range sitemaps {
+		index.Sitemaps = append(index.Sitemaps, indexEntry{Loc: s.Loc, LastMod: formatTime(s.LastMod)})
+	}
+	return marshalXML(index)
+}
+
+// Split 按 MaxURLs 分片
+func Split(urls []URL) [][]URL {
+	var parts [][]URL
+	for len(urls) > MaxURLs {
+		parts = append(parts, urls[:MaxURLs])
+		urls = urls[MaxURLs:]
+	}
+	return append(parts, urls)
+}
+
+// LastMod 返回一组 URL 中最新的修改时间
+func LastMod(urls []URL) time.Time {
+	var t time.Time
+	for _, u := range urls {
+		if u.LastMod.After(t) {
+			t = u.LastMod
+		}
+	}
+	return t
+}
+
+// RobotsGroup robots.txt 中的一组规则
+type RobotsGroup struct {
+	UserAgents []string
+	Allow      []string
+	Disallow   []string
+	CrawlDelay int
+}
+
+// Robots 生成 robots.txt，sitemaps 为 sitemap 的完整地址
+func Robots(groups []RobotsGroup, sitemaps ...string) []byte {
+	var sb strings.Builder
+	for i, g := range groups {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+
+		agents := g.UserAgents
+		if len(agents) == 0 {
+			agents = []string{"*"}
+		}
+		for _, ua := range agents {
+			fmt.Fprintf(&sb, "User-agent: %s\n", ua)
+		}
+		for _, p := range g.Allow {
+			fmt.Fprintf(&sb, "Allow: %s\n", p)
+		}
+		for _, p := range g.Disallow {
+			fmt.Fprintf(&sb, "Disallow: %s\n", p)
+		}
+		// 没有任何规则时写一个空 Disallow，表示全部允许
+		if len(g.Allow) == 0 && len(g.Disallow) == 0 {
+			sb.WriteString("Disallow:\n")
+		}
+		if g.CrawlDelay > 0 {
+			fmt.Fprintf(&sb, "Crawl-delay: %d\n", g.CrawlDelay)
+		}
+	}
+
+	if len(sitemaps) > 0 {
+		sb.WriteByte('\n')
+		for _, s := range sitemaps {
+			fmt.Fprintf(&sb, "Sitemap: %s\n", s)
+		}
+	}
+
+	return []byte(sb.String())
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,147 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	labelSpam = "spam"
+	labelHam  = "ham"
+
+	// 每个分类至少有这么多训练样本后才给出分数
+	bayesMinDocs = 10
+	// 单条内容参与计算的 token 上限
+	bayesMaxTokens = 300
+)
+
+// Bayes 朴素贝叶斯分类器，由审核员的判定训练，统计数据保存在 Redis
+type Bayes struct {
+	rdb *redis.Client
+}
+
+func NewBayes(rdb *redis.Client) *Bayes {
+	return &Bayes{rdb: rdb}
+}
+
+func (b *Bayes) Name() string {
+	return "bayes"
+}
+
+func (b *Bayes) Check(ctx context.Context, s *Submission) (Verdict, error) {
+	docs, err := b.rdb.HMGet(ctx, SpamBayesDocsKey(), labelSpam, labelHam).Result()
+	if err != nil {
+		return Verdict{}, err
+	}
+	nSpam, nHam := toFloat(docs[0]), toFloat(docs[1])
+	if nSpam < bayesMinDocs || nHam < bayesMinDocs {
+		return Verdict{Reason: "训练样本不足"}, nil
+	}
+
+	tokens := tokenize(s.Content)
+	if len(tokens) == 0 {
+		return Verdict{}, nil
+	}
+
+	pipe := b.rdb.Pipeline()
+	spamCmd := pipe.HMGet(ctx, SpamBayesTokensKey(labelSpam), tokens...)
+	hamCmd := pipe.HMGet(ctx, SpamBayesTokensKey(labelHam), tokens...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Verdict{}, err
+	}
+
+	// 拉普拉斯平滑后在对数空间累加，避免下溢
+	logSpam := math.Log(nSpam / (nSpam + nHam))
+	logHam := math.Log(nHam / (nSpam + nHam))
+	for i := range tokens {
+		logSpam += math.Log((toFloat(spamCmd.Val()[i]) + 1) / (nSpam + 2))
+		logHam += math.Log((toFloat(hamCmd.Val()[i]) + 1) / (nHam + 2))
+	}
+
+	score := 1 / (1 + math.Exp(logHam-logSpam))
+	return Verdict{Score: score, Reason: fmt.Sprintf("%d 个 token", len(tokens))}, nil
+}
+
+// Train 用一条已判定的内容训练分类器，delta 为 -1 时撤销之前的训练
+func (b *Bayes) Train(ctx context.Context, text string, isSpam bool, delta int64) error {
+	label := labelHam
+	if isSpam {
+		label = labelSpam
+	}
+
+	pipe := b.rdb.TxPipeline()
+	for _, t := range tokenize(text) {
+		pipe.HIncrBy(ctx, SpamBayesTokensKey(label), t, delta)
+	}
+	pipe.HIncrBy(ctx, SpamBayesDocsKey(), label, delta)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// tokenize 拉丁字母和数字按单词切分，中日韩文字按二元组切分，结果去重
+func tokenize(text string) []string {
+	seen := map[string]struct{}{}
+	tokens := []string{}
+	add := func(t string) {
+		if _, ok := seen[t]; ok || len(tokens) >= bayesMaxTokens {
+			return
+		}
+		seen[t] = struct{}{}
+		tokens = append(tokens, t)
+	}
+
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if n := len(word); n >= 2 && n <= 30 {
+			add(string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			add(string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			add(string(cjk[i : i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+func toFloat(v any) float64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return max(f, 0)
+}
@@ -0,0 +1,203 @@
+package spam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ---------------------------------------
+// 关键词 / 正则黑名单
+
+// Blocklist 命中任一规则即判定为垃圾，以 "re:" 开头的规则按正则匹配，其余按关键词匹配，均不区分大小写
+type Blocklist struct {
+	patterns []*regexp.Regexp
+}
+
+func NewBlocklist(rules []string) *Blocklist {
+	b := &Blocklist{}
+	for _, rule := range rules {
+		expr := regexp.QuoteMeta(rule)
+		if strings.HasPrefix(rule, "re:") {
+			expr = strings.TrimPrefix(rule, "re:")
+		}
+
+		re, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			log.Printf("忽略无效的黑名单规则 %q: %v", rule, err)
+			continue
+		}
+		b.patterns = append(b.patterns, re)
+	}
+	return b
+}
+
+func (b *Blocklist) Name() string {
+	return "blocklist"
+}
+
+func (b *Blocklist) Check(ctx context.Context, s *Submission) (Verdict, error) {
+	fields := []string{s.Content, s.AuthorName, s.Email, s.Website}
+	for _, re := range b.patterns {
+		for _, f := range fields {
+			if re.MatchString(f) {
+				return Verdict{Score: 1, Reason: "命中黑名单 " + re.String()}, nil
+			}
+		}
+	}
+	return Verdict{}, nil
+}
+
+// ---------------------------------------
+// 链接数量
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// LinkCount 正文链接超过 max 个时逐步加分
+type LinkCount struct {
+	max int
+}
+
+func NewLinkCount(max int) *LinkCount {
+	return &LinkCount{max: max}
+}
+
+func (l *LinkCount) Name() string {
+	return "links"
+}
+
+func (l *LinkCount) Check(ctx context.Context, s *Submission) (Verdict, error) {
+	n := len(linkPattern.FindAllStringIndex(s.Content, -1))
+	if n <= l.max {
+		return Verdict{}, nil
+	}
+
+	score := min(1, 0.5+0.1*float64(n-l.max))
+	return Verdict{Score: score, Reason: fmt.Sprintf("包含 %d 个链接", n)}, nil
+}
+
+// ---------------------------------------
+// 蜜罐字段
+
+// Honeypot 前端隐藏的字段被填写时判定为机器提交
+type Honeypot struct{}
+
+func (Honeypot) Name() string {
+	return "honeypot"
+}
+
+func (Honeypot) Check(ctx context.Context, s *Submission) (Verdict, error) {
+	if s.Honeypot != "" {
+		return Verdict{Score: 1, Reason: "蜜罐字段被填写"}, nil
+	}
+	return Verdict{}, nil
+}
+
+// ---------------------------------------
+// 最短填写时间
+
+// formTokenMaxAge 表单 token 的有效期，超过后视为无效
+const formTokenMaxAge = 2 * time.Hour
+
+// MinSubmitTime 从表单渲染到提交的耗时少于 min 时判定为机器提交
+// 渲染时间来自 Token 签发的 token，token 绑定文章且只能使用一次；
+// 登录用户不检查，匿名提交缺少 token 时给出低于审核阈值的分数，token 无效或重复使用时给出较高分数
+type MinSubmitTime struct {
+	rdb    *redis.Client // 记录已使用的 nonce，为 nil 时不检查重放
+	secret []byte
+	min    time.Duration
+}
+
+func NewMinSubmitTime(rdb *redis.Client, secret string, min time.Duration) *MinSubmitTime {
+	return &MinSubmitTime{rdb: rdb, secret: []byte(secret), min: min}
+}
+
+func (m *MinSubmitTime) Name() string {
+	return "submitTime"
+}
+
+func (m *MinSubmitTime) Check(ctx context.Context, s *Submission) (Verdict, error) {
+	if s.Authenticated {
+		return Verdict{}, nil
+	}
+	if s.FormToken == "" {
+		return Verdict{Score: 0.3, Reason: "缺少表单 token"}, nil
+	}
+
+	t, ok := m.verify(s.FormToken)
+	if !ok || t.articleID != s.ArticleID {
+		return Verdict{Score: 0.8, Reason: "表单 token 无效"}, nil
+	}
+
+	elapsed := s.SubmittedAt.Sub(t.renderedAt)
+	if elapsed > formTokenMaxAge {
+		return Verdict{Score: 0.5, Reason: "表单 token 已过期"}, nil
+	}
+	if elapsed < m.min {
+		return Verdict{Score: 1, Reason: fmt.Sprintf("填写耗时 %v", elapsed.Round(time.Millisecond))}, nil
+	}
+
+	if m.rdb != nil {
+		fresh, err := m.rdb.SetNX(ctx, SpamFormNonceKey(t.nonce), 1, formTokenMaxAge).Result()
+		if err != nil {
+			return Verdict{}, err
+		}
+		if !fresh {
+			return Verdict{Score: 0.8, Reason: "表单 token 已使用"}, nil
+		}
+	}
+	return Verdict{}, nil
+}
+
+// Token 为某篇文章的评论表单签发 token，格式为 articleID.unixMilli.nonce.base64url(HMAC-SHA256)
+func (m *MinSubmitTime) Token(articleID int, now time.Time) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+
+	payload := strconv.Itoa(articleID) + "." + strconv.FormatInt(now.UnixMilli(), 10) + "." + hex.EncodeToString(b)
+	return payload + "." + m.sign(payload)
+}
+
+type formToken struct {
+	articleID  int
+	renderedAt time.Time
+	nonce      string
+}
+
+func (m *MinSubmitTime) verify(token string) (formToken, bool) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 || !hmac.Equal([]byte(token[i+1:]), []byte(m.sign(token[:i]))) {
+		return formToken{}, false
+	}
+
+	parts := strings.Split(token[:i], ".")
+	if len(parts) != 3 {
+		return formToken{}, false
+	}
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return formToken{}, false
+	}
+	ms, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return formToken{}, false
+	}
+	return formToken{articleID: id, renderedAt: time.UnixMilli(ms), nonce: parts[2]}, true
+}
+
+func (m *MinSubmitTime) sign(payload string) string {
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package spam
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMinSubmitTime(t *testing.T) {
+	m := NewMinSubmitTime(nil, "0123456789abcdef0123456789abcdef", 3*time.Second)
+	other := NewMinSubmitTime(nil, "fedcba9876543210fedcba9876543210", 3*time.Second)
+	rendered := time.Unix(1_700_000_000, 0)
+	token := m.Token(7, rendered)
+
+	tests := []struct {
+		name  string
+		s     Submission
+		score float64
+	}{
+		{"登录用户不检查", Submission{Authenticated: true, ArticleID: 7, SubmittedAt: rendered}, 0},
+		{"缺少 token", Submission{ArticleID: 7, SubmittedAt: rendered}, 0.3},
+		{"正常填写", Submission{FormToken: token, ArticleID: 7, SubmittedAt: rendered.Add(time.Minute)}, 0},
+		{"填写过快", Submission{FormToken: token, ArticleID: 7, SubmittedAt: rendered.Add(time.Second)}, 1},
+		{"已过期", Submission{FormToken: token, ArticleID: 7, SubmittedAt: rendered.Add(3 * time.Hour)}, 0.5},
+		{"文章不符", Submission{FormToken: token, ArticleID: 8, SubmittedAt: rendered.Add(time.Minute)}, 0.8},
+		{"密钥不符", Submission{FormToken: other.Token(7, rendered), ArticleID: 7, SubmittedAt: rendered.Add(time.Minute)}, 0.8},
+		{"格式错误", Submission{FormToken: "123.abc", ArticleID: 7, SubmittedAt: rendered.Add(time.Minute)}, 0.8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := m.Check(context.Background(), &tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Score != tt.score {
+				t.Fatalf("score = %v (%s), want %v", v.Score, v.Reason, tt.score)
+			}
+		})
+	}
+}
+
+func TestTokenNonceUnique(t *testing.T) {
+	m := NewMinSubmitTime(nil, "0123456789abcdef0123456789abcdef", 0)
+	now := time.Now()
+	a, _ := m.verify(m.Token(1, now))
+	b, _ := m.verify(m.Token(1, now))
+	if a.nonce == "" || a.nonce == b.nonce {
+		t.Fatalf("nonce 应随机生成: %q %q", a.nonce, b.nonce)
+	}
+}
+
+func TestFilterTakesMax(t *testing.T) {
+	f := NewFilter(NewLinkCount(2), Honeypot{}, NewMinSubmitTime(nil, "0123456789abcdef0123456789abcdef", time.Second))
+
+	tests := []struct {
+		name  string
+		s     Submission
+		score float64
+	}{
+		{"匿名无 token 低于审核阈值", Submission{Content: "hello"}, 0.3},
+		{"蜜罐", Submission{Content: "hello", Honeypot: "x", Authenticated: true}, 1},
+		{"链接过多", Submission{Content: "http://a http://b http://c http://d", Authenticated: true}, 0.7},
+		{"登录用户", Submission{Content: "hello", Authenticated: true}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, _ := f.Check(context.Background(), &tt.s)
+			if diff := score - tt.score; diff > 1e-9 || diff < -1e-9 {
+				t.Fatalf("score = %v, want %v", score, tt.score)
+			}
+		})
+	}
+}
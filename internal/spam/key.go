@@ -0,0 +1,20 @@
+package spam
+
+import (
+	"fmt"
+)
+
+// SpamBayesTokensKey 各分类下 token 出现的文档数，label 为 spam 或 ham
+func SpamBayesTokensKey(label string) string {
+	return fmt.Sprintf("Spam:Bayes:Tokens:%s", label)
+}
+
+// SpamBayesDocsKey 各分类的训练文档数
+func SpamBayesDocsKey() string {
+	return "Spam:Bayes:Docs"
+}
+
+// SpamFormNonceKey 已使用过的评论表单 token
+func SpamFormNonceKey(nonce string) string {
+	return fmt.Sprintf("Spam:FormNonce:%s", nonce)
+}
@@ -0,0 +1,70 @@
+package spam
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Submission 待检测的访客提交，如评论
+type Submission struct {
+	Content    string
+	AuthorName string
+	Email      string
+	Website    string
+	IP         string
+	UserAgent  string
+	Honeypot   string // 隐藏字段的值，正常用户不会填写
+	FormToken  string // 表单渲染时签发的 token，用于计算填写耗时
+	ArticleID  int    // 评论的文章，须与 token 绑定的文章一致
+	// Authenticated 是否登录用户提交，登录用户不检查表单 token
+	Authenticated bool
+	SubmittedAt   time.Time
+}
+
+// Verdict 单个检测器的结论，Score 取值 0~1，越大越可能是垃圾内容
+type Verdict struct {
+	Checker string  `json:"checker"`
+	Score   float64 `json:"score"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// SpamChecker 垃圾内容检测器
+type SpamChecker interface {
+	Name() string
+	Check(ctx context.Context, s *Submission) (Verdict, error)
+}
+
+// Filter 依次执行检测器，取最高分作为最终得分
+type Filter struct {
+	checkers []SpamChecker
+}
+
+func NewFilter(checkers ...SpamChecker) *Filter {
+	return &Filter{checkers: checkers}
+}
+
+// Check 返回最终得分和每个检测器的结论，出错的检测器会被跳过
+func (f *Filter) Check(ctx context.Context, s *Submission) (float64, []Verdict) {
+	if s.SubmittedAt.IsZero() {
+		s.SubmittedAt = time.Now()
+	}
+
+	score := 0.0
+	verdicts := make([]Verdict, 0, len(f.checkers))
+	for _, c := range f.checkers {
+		v, err := c.Check(ctx, s)
+		if err != nil {
+			log.Printf("垃圾检测失败 checker=%s: %v", c.Name(), err)
+			continue
+		}
+		v.Checker = c.Name()
+		verdicts = append(verdicts, v)
+
+		if v.Score > score {
+			score = v.Score
+		}
+	}
+
+	return score, verdicts
+}
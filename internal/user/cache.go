@@ -0,0 +1,60 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrTokenNotFound = errors.New("refresh token not found")
+)
+
+func cacheSetRefreshToken(ctx context.Context, rdb *redis.Client, tokenHash string, userID int, ttl time.Duration) error {
+	pipe := rdb.TxPipeline()
+	pipe.Set(ctx, UserRefreshTokenKey(tokenHash), userID, ttl)
+	pipe.SAdd(ctx, UserRefreshSetKey(userID), tokenHash)
+	pipe.Expire(ctx, UserRefreshSetKey(userID), ttl)
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// cacheTakeRefreshToken 原子地取出并删除 refresh token，保证每个 token 只能使用一次
+func cacheTakeRefreshToken(ctx context.Context, rdb *redis.Client, tokenHash string) (int, error) {
+	data, err := rdb.GetDel(ctx, UserRefreshTokenKey(tokenHash)).Result()
+	if err == redis.Nil {
+		return 0, ErrTokenNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("缓存获取异常 %w", err)
+	}
+
+	userID, err := strconv.Atoi(data)
+	if err != nil {
+		return 0, fmt.Errorf("用户ID解析失败 %w", err)
+	}
+
+	rdb.SRem(ctx, UserRefreshSetKey(userID), tokenHash)
+	return userID, nil
+}
+
+// cacheDelUserRefreshTokens 删除用户的全部 refresh token
+func cacheDelUserRefreshTokens(ctx context.Context, rdb *redis.Client, userID int) error {
+	hashes, err := rdb.SMembers(ctx, UserRefreshSetKey(userID)).Result()
+	if err != nil {
+		return fmt.Errorf("缓存获取异常 %w", err)
+	}
+
+	keys := make([]string, 0, len(hashes)+1)
+	for _, h := range hashes {
+		keys = append(keys, UserRefreshTokenKey(h))
+	}
+	keys = append(keys, UserRefreshSetKey(userID))
+
+	return rdb.Del(ctx, keys...).Err()
+}
@@ -0,0 +1,31 @@
+package user
+
+import (
+	"gorm.io/gorm"
+)
+
+func repoGetUserByUsername(db *gorm.DB, username string) (*User, error) {
+	var user User
+
+	result := db.Where("username = ?", username).First(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
+
+func repoGetUserByID(db *gorm.DB, id int) (*User, error) {
+	var user User
+
+	result := db.First(&user, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &user, nil
+}
+
+func repoCreateUser(db *gorm.DB, user *User) error {
+	return db.Create(user).Error
+}
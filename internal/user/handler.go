@@ -0,0 +1,88 @@
+package user
+
+import (
+	"my_web/backend/internal/httpserver"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	httpserver.BaseHandler
+	service *Service
+}
+
+func NewHandler(s *Service) *Handler {
+	return &Handler{
+		service: s,
+	}
+}
+
+func (h *Handler) RegisterRoutes(e *gin.Engine) {
+	r := e.Group("/api/auth")
+	{
+		r.POST("/login", h.login)
+		r.POST("/refresh", h.refresh)
+		r.POST("/logout", h.logout)
+	}
+}
+
+// 登录
+func (h *Handler) login(ctx *gin.Context) {
+	var req LoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.Login(ctx.Request.Context(), &req)
+	switch err {
+	case nil:
+		h.Success(ctx, data)
+	case ErrUserNotExist:
+		h.Fail(ctx, httpserver.ErrUserExist, nil)
+	case ErrWrongPassword:
+		h.Fail(ctx, httpserver.ErrPassword, nil)
+	default:
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+	}
+}
+
+// 刷新 token
+func (h *Handler) refresh(ctx *gin.Context) {
+	var req RefreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	data, err := h.service.Refresh(ctx.Request.Context(), req.RefreshToken)
+	switch err {
+	case nil:
+		h.Success(ctx, data)
+	case ErrInvalidToken:
+		h.Fail(ctx, httpserver.ErrToken, nil)
+	case ErrUserNotExist:
+		h.Fail(ctx, httpserver.ErrUserExist, nil)
+	default:
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+	}
+}
+
+// 注销
+func (h *Handler) logout(ctx *gin.Context) {
+	var req LogoutRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		h.Fail(ctx, httpserver.ErrRequest, err)
+		return
+	}
+
+	err := h.service.Logout(ctx.Request.Context(), req.RefreshToken, req.All)
+	switch err {
+	case nil:
+		h.Success(ctx, nil)
+	case ErrInvalidToken:
+		h.Fail(ctx, httpserver.ErrToken, nil)
+	default:
+		h.Fail(ctx, httpserver.ErrDBOp, err)
+	}
+}
@@ -0,0 +1,15 @@
+package user
+
+import (
+	"fmt"
+)
+
+// UserRefreshTokenKey refresh token 的哈希 -> 用户ID
+func UserRefreshTokenKey(tokenHash string) string {
+	return fmt.Sprintf("User:Refresh:%s", tokenHash)
+}
+
+// UserRefreshSetKey 用户持有的 refresh token 哈希集合
+func UserRefreshSetKey(userID int) string {
+	return fmt.Sprintf("User:RefreshSet:%d", userID)
+}
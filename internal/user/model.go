@@ -0,0 +1,54 @@
+package user
+
+import (
+	"my_web/backend/internal/middleware"
+	"time"
+)
+
+const (
+	RoleAdmin  = middleware.RoleAdmin
+	RoleEditor = middleware.RoleEditor
+	RoleReader = middleware.RoleReader
+)
+
+type User struct {
+	ID           int       `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Username     string    `gorm:"size:64;uniqueIndex" json:"username"` // 登录名
+	PasswordHash string    `json:"-"`                                   // bcrypt 哈希
+	Nickname     string    `gorm:"size:64" json:"nickname"`             // 昵称
+	Role         string    `gorm:"size:32" json:"role"`                 // 角色
+}
+
+// DisplayName 优先返回昵称
+func (u *User) DisplayName() string {
+	if u.Nickname != "" {
+		return u.Nickname
+	}
+	return u.Username
+}
+
+// ---------------------------------------
+
+type LoginRequest struct {
+	Username string `json:"username" binding:"required,max=64"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+	All          bool   `json:"all"` // 是否注销该用户的全部 refresh token
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"accessToken"`
+	AccessExpiresAt  time.Time `json:"accessExpiresAt"`
+	RefreshToken     string    `json:"refreshToken"`
+	RefreshExpiresAt time.Time `json:"refreshExpiresAt"`
+	User             *User     `json:"user"`
+}
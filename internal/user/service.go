@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"my_web/backend/internal/config"
+	"my_web/backend/internal/middleware"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotExist  = errors.New("user not exist")
+	ErrWrongPassword = errors.New("wrong password")
+	ErrInvalidToken  = errors.New("invalid refresh token")
+)
+
+const (
+	defaultRefreshTTL    = 7 * 24 * time.Hour
+	refreshTokenByteSize = 32
+)
+
+type Service struct {
+	DB  *gorm.DB
+	RDB *redis.Client
+
+	conf *config.AuthConfig
+}
+
+func NewUserService(db *gorm.DB, rdb *redis.Client, conf *config.AuthConfig) *Service {
+	return &Service{
+		DB:   db,
+		RDB:  rdb,
+		conf: conf,
+	}
+}
+
+// EnsureAdmin 配置了管理员账号且账号不存在时创建之
+func (s *Service) EnsureAdmin(ctx context.Context) error {
+	if s.conf.AdminUsername == "" || s.conf.AdminPassword == "" {
+		return nil
+	}
+
+	_, err := repoGetUserByUsername(s.DB.WithContext(ctx), s.conf.AdminUsername)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	hash, err := HashPassword(s.conf.AdminPassword)
+	if err != nil {
+		return err
+	}
+
+	err = repoCreateUser(s.DB.WithContext(ctx), &User{
+		Username:     s.conf.AdminUsername,
+		PasswordHash: hash,
+		Role:         RoleAdmin,
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("已创建管理员账号 %s", s.conf.AdminUsername)
+	return nil
+}
+
+// 用户名密码登录
+func (s *Service) Login(ctx context.Context, req *LoginRequest) (*TokenPair, error) {
+	user, err := repoGetUserByUsername(s.DB.WithContext(ctx), req.Username)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// 使用 refresh token 换取新的 token 对，旧 refresh token 立即失效
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	userID, err := cacheTakeRefreshToken(ctx, s.RDB, hashToken(refreshToken))
+	if err == ErrTokenNotFound {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := repoGetUserByID(s.DB.WithContext(ctx), userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, user)
+}
+
+// 注销 refresh token，all 为 true 时注销该用户全部 refresh token
+func (s *Service) Logout(ctx context.Context, refreshToken string, all bool) error {
+	userID, err := cacheTakeRefreshToken(ctx, s.RDB, hashToken(refreshToken))
+	if err == ErrTokenNotFound {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	if all {
+		return cacheDelUserRefreshTokens(ctx, s.RDB, userID)
+	}
+	return nil
+}
+
+func (s *Service) issueTokens(ctx context.Context, user *User) (*TokenPair, error) {
+	accessToken, accessExpiresAt, err := middleware.GenerateToken(user.ID, user.DisplayName(), []string{user.Role})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.conf.RefreshTokenTTL
+	if ttl <= 0 {
+		ttl = defaultRefreshTTL
+	}
+	if err := cacheSetRefreshToken(ctx, s.RDB, hashToken(refreshToken), user.ID, ttl); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: time.Now().Add(ttl),
+		User:             user,
+	}, nil
+}
+
+// HashPassword 使用 bcrypt 生成密码哈希
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func randomToken() (string, error) {
+	buf := make([]byte, refreshTokenByteSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken Redis 中只保存 refresh token 的哈希
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// apply 由编辑序列还原出原文和新文，并返回编辑行数
+func apply(ops []diffOp) (a, b []string, edits int) {
+	for _, op := range ops {
+		if op.kind != '+' {
+			a = append(a, op.text)
+		}
+		if op.kind != '-' {
+			b = append(b, op.text)
+		}
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	return a, b, edits
+}
+
+// lcsEdits 用动态规划求最少编辑行数，作为对照
+func lcsEdits(a, b []string) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				dp[i][j] = dp[i+1][j+1] + 1
+			} else {
+				dp[i][j] = max(dp[i+1][j], dp[i][j+1])
+			}
+		}
+	}
+	return len(a) + len(b) - 2*dp[0][0]
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		edits int
+	}{
+		{"都为空", "", "", 0},
+		{"相同", "a b c", "a b c", 0},
+		{"全部新增", "", "a b", 2},
+		{"全部删除", "a b", "", 2},
+		{"中间插入", "a c", "a b c", 1},
+		{"中间删除", "a b c", "a c", 1},
+		{"替换", "a b c", "a x c", 2},
+		{"完全不同", "a b c", "x y z", 6},
+		{"重复行", "a a a b", "a b a a", 2},
+		{"交错", "a b c a b b a", "c b a b a c", 5},
+		{"奇数差", "a b c d e", "b d", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+			gotA, gotB, edits := apply(diffLines(a, b))
+			if strings.Join(gotA, " ") != tt.a || strings.Join(gotB, " ") != tt.b {
+				t.Fatalf("ops rebuild %q -> %q, want %q -> %q", gotA, gotB, tt.a, tt.b)
+			}
+			if edits != tt.edits {
+				t.Errorf("edits = %d, want %d", edits, tt.edits)
+			}
+		})
+	}
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	gen := func() []string {
+		lines := make([]string, r.Intn(30))
+		for i := range lines {
+			lines[i] = strconv.Itoa(r.Intn(4))
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := gen(), gen()
+		gotA, gotB, edits := apply(diffLines(a, b))
+		if strings.Join(gotA, ",") != strings.Join(a, ",") || strings.Join(gotB, ",") != strings.Join(b, ",") {
+			t.Fatalf("ops do not rebuild %v -> %v", a, b)
+		}
+		if want := lcsEdits(a, b); edits != want {
+			t.Fatalf("%v -> %v: edits = %d, want %d", a, b, edits, want)
+		}
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	a := make([]string, 3000)
+	b := make([]string, 3000)
+	for i := range a {
+		a[i] = "a" + strconv.Itoa(i)
+		b[i] = "b" + strconv.Itoa(i)
+	}
+	if _, _, edits := apply(diffLines(a, b)); edits != 6000 {
+		t.Errorf("edits = %d, want 6000", edits)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"相同", "a\nb\n", "a\nb\n", ""},
+		{"修改一行", "a\nb\nc\n", "a\nx\nc\n", "--- v1\n+++ v2\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"新建", "", "a\n", "--- v1\n+++ v2\n@@ -0,0 +1 @@\n+a\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("v1", "v2", tt.a, tt.b, 3); got != tt.want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
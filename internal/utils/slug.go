@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// maxSlugLen slug 最大长度（字节）
+const maxSlugLen = 80
+
+var pinyinArgs = func() pinyin.Args {
+	args := pinyin.NewArgs()
+	args.Style = pinyin.Normal
+	// 无法转写的字符（如日文假名、韩文）原样丢弃
+	args.Fallback = func(r rune, a pinyin.Args) []string { return nil }
+	return args
+}()
+
+// Slugify 将标题转为 URL 友好的 slug
+// ASCII 字母数字转小写保留，汉字转写为不带声调的拼音，其余字符视为分隔符；
+// 结果可能为空，调用方需自行兜底
+func Slugify(title string) string {
+	words := []string{}
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			words = append(words, word.String())
+			word.Reset()
+		}
+	}
+
+	for _, r := range title {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			word.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				words = append(words, py[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	var sb strings.Builder
+	for _, w := range words {
+		if sb.Len() == 0 {
+			// 首个单词超长时截断，避免整个 slug 为空
+			sb.WriteString(w[:min(len(w), maxSlugLen)])
+			continue
+		}
+		if sb.Len()+1+len(w) > maxSlugLen {
+			break
+		}
+		sb.WriteByte('-')
+		sb.WriteString(w)
+	}
+	return sb.String()
+}
+
+// SuffixSlug 在 slug 后追加 -n，必要时截短 slug 使结果不超过最大长度
+func SuffixSlug(slug string, n int) string {
+	suffix := "-" + strconv.Itoa(n)
+	if len(slug)+len(suffix) > maxSlugLen {
+		slug = strings.TrimRight(slug[:maxSlugLen-len(suffix)], "-")
+	}
+	return slug + suffix
+}
+
+// IsSlug 判断字符串是否为合法 slug：小写字母数字，以单个 - 分隔
+func IsSlug(s string) bool {
+	if s == "" || len(s) > maxSlugLen {
+		return false
+	}
+	prevDash := true
+	for _, r := range s {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			prevDash = false
+		case r == '-' && !prevDash:
+			prevDash = true
+		default:
+			return false
+		}
+	}
+	return !prevDash
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	long := strings.Repeat("a", maxSlugLen)
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{"英文", "Hello, World!", "hello-world"},
+		{"大小写与数字", "Go 1.25 Release Notes", "go-1-25-release-notes"},
+		{"中文转拼音", "你好世界", "ni-hao-shi-jie"},
+		{"中英混排", "Go语言入门", "go-yu-yan-ru-men"},
+		{"中英混排带符号", "Redis 缓存（二）：淘汰策略", "redis-huan-cun-er-tao-tai-ce-lve"},
+		{"无法转写的字符被丢弃", "こんにちは world", "world"},
+		{"全角与特殊字符作分隔符", "  a—b…c  ", "a-b-c"},
+		{"非 ASCII 字母视为分隔符", "café naïve", "caf-na-ve"},
+		{"没有可用字符", "！？…", ""},
+		{"空标题", "", ""},
+		{"恰好最大长度的单词", long, long},
+		{"超长单词被截断", long + "bcd", long},
+		{"超长时按单词截断", strings.Repeat("abcd ", 20), strings.TrimSuffix(strings.Repeat("abcd-", 16), "-")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Slugify(tt.title)
+			if got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+			if got != "" && !IsSlug(got) {
+				t.Errorf("Slugify(%q) = %q, not a valid slug", tt.title, got)
+			}
+		})
+	}
+}
+
+func TestIsSlug(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"hello-world", true},
+		{"go-1-25", true},
+		{"a", true},
+		{strings.Repeat("a", maxSlugLen), true},
+		{strings.Repeat("a", maxSlugLen+1), false},
+		{"", false},
+		{"Hello", false},
+		{"-hello", false},
+		{"hello-", false},
+		{"hello--world", false},
+		{"hello_world", false},
+		{"hello world", false},
+		{"你好", false},
+	}
+	for _, tt := range tests {
+		if got := IsSlug(tt.s); got != tt.want {
+			t.Errorf("IsSlug(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestSuffixSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		slug string
+		n    int
+		want string
+	}{
+		{"短 slug 直接追加", "hello", 2, "hello-2"},
+		{"超长时截短", strings.Repeat("a", maxSlugLen), 2, strings.Repeat("a", maxSlugLen-2) + "-2"},
+		{"多位序号", strings.Repeat("a", maxSlugLen), 123, strings.Repeat("a", maxSlugLen-4) + "-123"},
+		{"截断处的 - 被去掉", strings.Repeat("a", maxSlugLen-4) + "-bcd", 12, strings.Repeat("a", maxSlugLen-4) + "-12"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuffixSlug(tt.slug, tt.n)
+			if got != tt.want {
+				t.Errorf("SuffixSlug(%q, %d) = %q, want %q", tt.slug, tt.n, got, tt.want)
+			}
+			if !IsSlug(got) {
+				t.Errorf("SuffixSlug(%q, %d) = %q, not a valid slug", tt.slug, tt.n, got)
+			}
+		})
+	}
+}
@@ -28,6 +28,7 @@ type TaskRunner struct {
 
 	running   bool
 	executing bool
+	pending   bool // 执行期间收到 Trigger，结束后再执行一次
 	mu        *sync.Mutex
 }
 
@@ -152,6 +153,31 @@ func (s *TaskRunner) runLoop() {
 	}
 }
 
+// Trigger 立即异步执行一次任务，任务正在执行时在本次结束后补执行一次
+func (s *TaskRunner) Trigger() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+	if s.executing {
+		s.pending = true
+		return
+	}
+	s.executing = true
+
+	tCtx, tCancel := s.taskContext()
+	go s.ExecuteOnce(tCtx, tCancel)
+}
+
+func (s *TaskRunner) taskContext() (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(s.ctx, s.timeout)
+	}
+	return context.WithCancel(s.ctx)
+}
+
 // sync excute task once
 func (s *TaskRunner) ExecuteOnce(ctx context.Context, cancel context.CancelFunc) {
 	defer func() {
@@ -163,7 +189,13 @@ func (s *TaskRunner) ExecuteOnce(ctx context.Context, cancel context.CancelFunc)
 		}
 		s.mu.Lock()
 		s.executing = false
+		rerun := s.pending && s.running
+		s.pending = false
 		s.mu.Unlock()
+
+		if rerun {
+			s.Trigger()
+		}
 	}()
 
 	s.handler.Run(ctx)